@@ -0,0 +1,151 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/johnhoman/kubeflow-profile-manager/apis/v1alpha1"
+)
+
+const (
+	// AnnotationConversionData holds the v1alpha1 spec fields that have no v1
+	// equivalent so that they survive a round trip through v1
+	AnnotationConversionData = "profiles.kubeflow.org/conversion-data"
+
+	errNotHubProfile           = "hub is not a v1alpha1 Profile"
+	errUnmarshalConversionData = "failed to unmarshal conversion data"
+	errMarshalConversionData   = "failed to marshal conversion data"
+)
+
+// ConvertTo converts this Profile to the v1alpha1 hub. The status is owned
+// by the controller and written through the hub, so it is not converted.
+func (p *Profile) ConvertTo(dst conversion.Hub) error {
+	hub, ok := dst.(*v1alpha1.Profile)
+	if !ok {
+		return errors.New(errNotHubProfile)
+	}
+	hub.ObjectMeta = *p.ObjectMeta.DeepCopy()
+	hub.Spec = v1alpha1.ProfileSpec{}
+	if data, ok := hub.Annotations[AnnotationConversionData]; ok {
+		if err := json.Unmarshal([]byte(data), &hub.Spec); err != nil {
+			return errors.Wrap(err, errUnmarshalConversionData)
+		}
+		delete(hub.Annotations, AnnotationConversionData)
+		if len(hub.Annotations) == 0 {
+			hub.Annotations = nil
+		}
+	}
+	if hub.Spec.DeletionPolicy == "" {
+		hub.Spec.DeletionPolicy = v1alpha1.DeletionPolicyDelete
+	}
+	hub.Spec.Owner = p.Spec.Owner
+	hub.Spec.Plugins = nil
+	for _, plugin := range p.Spec.Plugins {
+		hub.Spec.Plugins = append(hub.Spec.Plugins, v1alpha1.Plugin{
+			TypeMeta: plugin.TypeMeta,
+			Spec:     plugin.Spec.DeepCopy(),
+		})
+	}
+	hub.Spec.ResourceQuotaSpec = nil
+	if !apiequality.Semantic.DeepEqual(p.Spec.ResourceQuotaSpec, corev1.ResourceQuotaSpec{}) {
+		hub.Spec.ResourceQuotaSpec = p.Spec.ResourceQuotaSpec.DeepCopy()
+	}
+	return nil
+}
+
+// ConvertFrom converts the v1alpha1 hub to this Profile. The hub Ready
+// condition is reported as the upstream Successful or Failed condition.
+func (p *Profile) ConvertFrom(src conversion.Hub) error {
+	hub, ok := src.(*v1alpha1.Profile)
+	if !ok {
+		return errors.New(errNotHubProfile)
+	}
+	p.ObjectMeta = *hub.ObjectMeta.DeepCopy()
+	if err := setConversionData(p, hub); err != nil {
+		return err
+	}
+	p.Spec.Owner = hub.Spec.Owner
+	p.Spec.Plugins = nil
+	for _, plugin := range hub.Spec.Plugins {
+		p.Spec.Plugins = append(p.Spec.Plugins, Plugin{
+			TypeMeta: plugin.TypeMeta,
+			Spec:     plugin.Spec.DeepCopy(),
+		})
+	}
+	p.Spec.ResourceQuotaSpec = corev1.ResourceQuotaSpec{}
+	if hub.Spec.ResourceQuotaSpec != nil {
+		p.Spec.ResourceQuotaSpec = *hub.Spec.ResourceQuotaSpec.DeepCopy()
+	}
+
+	p.Status.Conditions = nil
+	ready := hub.Status.GetCondition(v1alpha1.TypeReady)
+	switch ready.Status {
+	case corev1.ConditionTrue:
+		p.Status.Conditions = append(p.Status.Conditions, ProfileCondition{
+			Type:    ProfileSucceed,
+			Status:  string(ready.Status),
+			Message: ready.Message,
+		})
+	case corev1.ConditionFalse:
+		p.Status.Conditions = append(p.Status.Conditions, ProfileCondition{
+			Type:    ProfileFailed,
+			Status:  string(ready.Status),
+			Message: ready.Message,
+		})
+	}
+	return nil
+}
+
+var _ conversion.Convertible = &Profile{}
+
+// setConversionData stores the hub spec fields that have no v1 equivalent in
+// the conversion data annotation. The annotation is only written when one of
+// those fields is set to something other than its default.
+func setConversionData(p *Profile, hub *v1alpha1.Profile) error {
+	rest := hub.Spec.DeepCopy()
+	rest.Owner = rbacv1.Subject{}
+	rest.Plugins = nil
+	rest.ResourceQuotaSpec = nil
+	if rest.DeletionPolicy == v1alpha1.DeletionPolicyDelete {
+		// restored by ConvertTo
+		rest.DeletionPolicy = ""
+	}
+	if apiequality.Semantic.DeepEqual(*rest, v1alpha1.ProfileSpec{}) {
+		return nil
+	}
+	data, err := json.Marshal(rest)
+	if err != nil {
+		return errors.Wrap(err, errMarshalConversionData)
+	}
+	// the owner is converted directly, so the empty owner is left out
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return errors.Wrap(err, errMarshalConversionData)
+	}
+	delete(fields, "owner")
+	if data, err = json.Marshal(fields); err != nil {
+		return errors.Wrap(err, errMarshalConversionData)
+	}
+	if p.Annotations == nil {
+		p.Annotations = make(map[string]string)
+	}
+	p.Annotations[AnnotationConversionData] = string(data)
+	return nil
+}
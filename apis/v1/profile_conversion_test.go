@@ -0,0 +1,170 @@
+package v1
+
+import (
+	"testing"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	qt "github.com/frankban/quicktest"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+
+	"github.com/johnhoman/kubeflow-profile-manager/apis/v1alpha1"
+)
+
+func TestProfile_IsConvertible(t *testing.T) {
+	scheme := runtime.NewScheme()
+	qt.Assert(t, v1alpha1.AddToScheme(scheme), qt.IsNil)
+	qt.Assert(t, AddToScheme(scheme), qt.IsNil)
+
+	ok, err := conversion.IsConvertible(scheme, &v1alpha1.Profile{})
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, ok, qt.IsTrue)
+}
+
+func TestProfile_ConvertTo(t *testing.T) {
+	owner := rbacv1.Subject{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "starlord@guardians.net"}
+	plugin := Plugin{
+		TypeMeta: metav1.TypeMeta{Kind: "WorkloadIdentity"},
+		Spec:     &runtime.RawExtension{Raw: []byte(`{"gcpServiceAccount":"starlord@guardians.iam.gserviceaccount.com"}`)},
+	}
+
+	cases := map[string]struct {
+		profile *Profile
+		want    *v1alpha1.Profile
+	}{
+		"ConvertsTheSpec": {
+			profile: &Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: ProfileSpec{
+					Owner:   owner,
+					Plugins: []Plugin{plugin},
+					ResourceQuotaSpec: corev1.ResourceQuotaSpec{
+						Hard: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+					},
+				},
+			},
+			want: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner:   owner,
+					Plugins: []v1alpha1.Plugin{{TypeMeta: plugin.TypeMeta, Spec: plugin.Spec}},
+					ResourceQuotaSpec: &corev1.ResourceQuotaSpec{
+						Hard: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+					},
+					DeletionPolicy: v1alpha1.DeletionPolicyDelete,
+				},
+			},
+		},
+		"ConvertsAnEmptyQuotaToNil": {
+			profile: &Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec:       ProfileSpec{Owner: owner},
+			},
+			want: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec:       v1alpha1.ProfileSpec{Owner: owner, DeletionPolicy: v1alpha1.DeletionPolicyDelete},
+			},
+		},
+	}
+
+	for name, tt := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := &v1alpha1.Profile{}
+			qt.Assert(t, tt.profile.ConvertTo(got), qt.IsNil)
+			qt.Assert(t, got, qt.CmpEquals(cmp.Comparer(resourceEqual)), tt.want)
+
+			roundTrip := &Profile{}
+			qt.Assert(t, roundTrip.ConvertFrom(got), qt.IsNil)
+			qt.Assert(t, roundTrip, qt.CmpEquals(cmp.Comparer(resourceEqual)), tt.profile)
+		})
+	}
+}
+
+func TestProfile_ConvertFrom(t *testing.T) {
+	cases := map[string]struct {
+		status v1alpha1.ProfileStatus
+		want   []ProfileCondition
+	}{
+		"ReportsReadyAsSuccessful": {
+			status: v1alpha1.ProfileStatus{ConditionedStatus: xpv1.ConditionedStatus{
+				Conditions: []xpv1.Condition{{Type: v1alpha1.TypeReady, Status: corev1.ConditionTrue}},
+			}},
+			want: []ProfileCondition{{Type: ProfileSucceed, Status: "True"}},
+		},
+		"ReportsNotReadyAsFailed": {
+			status: v1alpha1.ProfileStatus{ConditionedStatus: xpv1.ConditionedStatus{
+				Conditions: []xpv1.Condition{{
+					Type:    v1alpha1.TypeReady,
+					Status:  corev1.ConditionFalse,
+					Message: "refusing to update namespace not owned by profile",
+				}},
+			}},
+			want: []ProfileCondition{{
+				Type:    ProfileFailed,
+				Status:  "False",
+				Message: "refusing to update namespace not owned by profile",
+			}},
+		},
+		"ReportsNothingBeforeTheFirstReconcile": {},
+	}
+
+	for name, tt := range cases {
+		t.Run(name, func(t *testing.T) {
+			profile := &Profile{}
+			hub := &v1alpha1.Profile{ObjectMeta: metav1.ObjectMeta{Name: "starlord"}, Status: tt.status}
+			qt.Assert(t, profile.ConvertFrom(hub), qt.IsNil)
+			qt.Assert(t, profile.Status.Conditions, qt.DeepEquals, tt.want)
+		})
+	}
+}
+
+func resourceEqual(a, b resource.Quantity) bool {
+	return a.Cmp(b) == 0
+}
+
+func TestProfile_ConvertFromPreservesHubFields(t *testing.T) {
+	hub := &v1alpha1.Profile{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "starlord",
+			Annotations: map[string]string{"guardians.net/team": "milano"},
+		},
+		Spec: v1alpha1.ProfileSpec{
+			Owner:          rbacv1.Subject{Kind: rbacv1.UserKind, Name: "starlord@guardians.net"},
+			DeletionPolicy: v1alpha1.DeletionPolicyOrphan,
+		},
+	}
+
+	profile := &Profile{}
+	qt.Assert(t, profile.ConvertFrom(hub), qt.IsNil)
+	qt.Assert(t, profile.Annotations, qt.DeepEquals, map[string]string{
+		"guardians.net/team":     "milano",
+		AnnotationConversionData: `{"deletionPolicy":"Orphan"}`,
+	})
+
+	got := &v1alpha1.Profile{}
+	qt.Assert(t, profile.ConvertTo(got), qt.IsNil)
+	qt.Assert(t, got, qt.DeepEquals, hub)
+}
+
+func TestProfile_ConvertFromSkipsDefaultHubFields(t *testing.T) {
+	hub := &v1alpha1.Profile{
+		ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+		Spec: v1alpha1.ProfileSpec{
+			Owner:          rbacv1.Subject{Kind: rbacv1.UserKind, Name: "starlord@guardians.net"},
+			DeletionPolicy: v1alpha1.DeletionPolicyDelete,
+		},
+	}
+
+	profile := &Profile{}
+	qt.Assert(t, profile.ConvertFrom(hub), qt.IsNil)
+	qt.Assert(t, profile.Annotations, qt.IsNil)
+
+	got := &v1alpha1.Profile{}
+	qt.Assert(t, profile.ConvertTo(got), qt.IsNil)
+	qt.Assert(t, got, qt.DeepEquals, hub)
+}
@@ -0,0 +1,103 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+)
+
+// Condition types of profiles and contributors. Conditions are the
+// crossplane-runtime Condition, which defines the Ready and Synced types
+const (
+	// TypeReady resources have all of their managed resources reconciled
+	TypeReady = xpv1.TypeReady
+	// TypeSynced resources were last reconciled without error
+	TypeSynced = xpv1.TypeSynced
+
+	TypeNamespaceReady           xpv1.ConditionType = "NamespaceReady"
+	TypeQuotaReady               xpv1.ConditionType = "QuotaReady"
+	TypeAuthorizationPolicyReady xpv1.ConditionType = "AuthorizationPolicyReady"
+	TypeOwnerContributorReady    xpv1.ConditionType = "OwnerContributorReady"
+	TypePluginsReady             xpv1.ConditionType = "PluginsReady"
+	TypeContributorsReady        xpv1.ConditionType = "ContributorsReady"
+	TypeLimitRangeReady          xpv1.ConditionType = "LimitRangeReady"
+	TypeTemplateObjectsReady     xpv1.ConditionType = "TemplateObjectsReady"
+	TypeNetworkPolicyReady       xpv1.ConditionType = "NetworkPolicyReady"
+	TypeKServeReady              xpv1.ConditionType = "KServeReady"
+	TypePeerAuthenticationReady  xpv1.ConditionType = "PeerAuthenticationReady"
+	TypeSidecarReady             xpv1.ConditionType = "SidecarReady"
+
+	// TypeQuotaWarning profiles use more of a resource quota than the
+	// controller warning threshold
+	TypeQuotaWarning xpv1.ConditionType = "QuotaWarning"
+	// TypeTerminating profiles are being torn down before they are deleted
+	TypeTerminating xpv1.ConditionType = "Terminating"
+
+	TypeServiceAccountReady             xpv1.ConditionType = "ServiceAccountReady"
+	TypeRoleBindingReady                xpv1.ConditionType = "RoleBindingReady"
+	TypePublicAuthorizationPolicyReady  xpv1.ConditionType = "PublicAuthorizationPolicyReady"
+	TypePrivateAuthorizationPolicyReady xpv1.ConditionType = "PrivateAuthorizationPolicyReady"
+)
+
+// Condition reasons of profiles and contributors
+const (
+	ReasonAvailable        = xpv1.ReasonAvailable
+	ReasonUnavailable      = xpv1.ReasonUnavailable
+	ReasonReconcileSuccess = xpv1.ReasonReconcileSuccess
+	ReasonReconcileError   = xpv1.ReasonReconcileError
+
+	ReasonCreated xpv1.ConditionReason = "Created"
+	ReasonUpdated xpv1.ConditionReason = "Updated"
+	ReasonStopped xpv1.ConditionReason = "Stopped"
+	ReasonPending xpv1.ConditionReason = "Pending"
+
+	ReasonDeletionProtected xpv1.ConditionReason = "DeletionProtected"
+	ReasonRevokingAccess    xpv1.ConditionReason = "RevokingAccess"
+	ReasonDeletingWorkloads xpv1.ConditionReason = "DeletingWorkloads"
+	ReasonDeletingNamespace xpv1.ConditionReason = "DeletingNamespace"
+	ReasonOrphaning         xpv1.ConditionReason = "Orphaning"
+
+	ReasonThresholdExceeded xpv1.ConditionReason = "ThresholdExceeded"
+)
+
+// RemoveConditions removes all conditions of the supplied types
+func (s *ProfileStatus) RemoveConditions(ct ...xpv1.ConditionType) {
+	removeConditions(&s.ConditionedStatus, ct...)
+}
+
+// RemoveConditions removes all conditions of the supplied types
+func (s *ContributorStatus) RemoveConditions(ct ...xpv1.ConditionType) {
+	removeConditions(&s.ConditionedStatus, ct...)
+}
+
+// removeConditions removes conditions from a crossplane-runtime status, which
+// only supports setting them
+func removeConditions(s *xpv1.ConditionedStatus, ct ...xpv1.ConditionType) {
+	conditions := make([]xpv1.Condition, 0, len(s.Conditions))
+	for _, c := range s.Conditions {
+		remove := false
+		for _, t := range ct {
+			if c.Type == t {
+				remove = true
+				break
+			}
+		}
+		if !remove {
+			conditions = append(conditions, c)
+		}
+	}
+	if len(conditions) == 0 {
+		conditions = nil
+	}
+	s.Conditions = conditions
+}
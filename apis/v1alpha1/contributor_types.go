@@ -13,6 +13,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -21,18 +22,37 @@ const (
 	ContributorRoleOwner       = "Owner"
 )
 
+// LabelSourceNamespace is set on contributors that are copied into the
+// additional namespaces of a profile. The value is the namespace of the
+// contributor that was copied.
+const LabelSourceNamespace = "contributor.kubeflow.org/source-namespace"
+
 // ContributorSpec defines the desired state of Profile
 type ContributorSpec struct {
 	Name string `json:"name"`
+	// +kubebuilder:validation:Enum=Owner;Contributor
 	Role string `json:"role"`
 }
 
 // ContributorStatus is the status of a contributor
-type ContributorStatus struct{}
+type ContributorStatus struct {
+	// Conditions of the contributor and each of its managed resources
+	xpv1.ConditionedStatus `json:",inline"`
+
+	// ObservedGeneration is the metadata.generation the conditions were set from
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// ClusterRole is the name of the ClusterRole bound to the contributor
+	ClusterRole string `json:"clusterRole,omitempty"`
+}
 
 // Contributor is the Schema for the profiles API
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="USER",type="string",JSONPath=".spec.name"
+// +kubebuilder:printcolumn:name="ROLE",type="string",JSONPath=".spec.role"
+// +kubebuilder:printcolumn:name="CLUSTERROLE",type="string",JSONPath=".status.clusterRole"
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
 type Contributor struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
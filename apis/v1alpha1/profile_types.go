@@ -13,15 +13,113 @@ limitations under the License.
 package v1alpha1
 
 import (
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
-type ProfileCondition struct {
-	Type    string `json:"type,omitempty"`
-	Status  string `json:"status,omitempty" description:"status of the condition, one of True, False, Unknown"`
-	Message string `json:"message,omitempty"`
+// Plugin is an extension that grants the profile access to external
+// resources. The plugin kind selects the implementation and the spec is
+// specific to the kind.
+type Plugin struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Spec of the plugin
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Spec *runtime.RawExtension `json:"spec,omitempty"`
+}
+
+// DeletionPolicy determines what happens to the profile namespace when the
+// profile is deleted
+// +kubebuilder:validation:Enum=Delete;Orphan
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the profile namespace with the profile
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyOrphan keeps the profile namespace, resource quota and
+	// policies when the profile is deleted
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+)
+
+// ProfileResourceQuota is a named ResourceQuota applied to each profile
+// namespace. Use the quota scopes and scope selector to limit the quota to
+// a subset of pods, such as BestEffort pods or pods with a PriorityClass.
+type ProfileResourceQuota struct {
+	// Name of the ResourceQuota
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	Name string `json:"name"`
+
+	// Spec of the ResourceQuota
+	Spec corev1.ResourceQuotaSpec `json:"spec"`
+}
+
+// ProfileIstio configures additional access to and from the workloads in the
+// profile namespaces. The principals, namespaces and rules are merged into the
+// control-plane-access AuthorizationPolicy.
+type ProfileIstio struct {
+	// Principals are additional source principals allowed to access every
+	// workload in the profile namespaces
+	// +optional
+	Principals []string `json:"principals,omitempty"`
+
+	// Namespaces are additional source namespaces allowed to access every
+	// workload in the profile namespaces
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// Rules allow sources to access specific paths and methods
+	// +optional
+	Rules []ProfileIstioRule `json:"rules,omitempty"`
+
+	// EgressHosts are additional hosts the workloads in the profile
+	// namespaces can reach, in namespace/dnsName format. They are added to
+	// the egress hosts configured for every profile
+	// +optional
+	EgressHosts []string `json:"egressHosts,omitempty"`
+}
+
+// ProfileIstioRule allows requests that match all of its fields. At least one
+// field must be set.
+type ProfileIstioRule struct {
+	// Principals of the request source. Matches any principal when empty
+	// +optional
+	Principals []string `json:"principals,omitempty"`
+
+	// Namespaces of the request source. Matches any namespace when empty
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// Paths of the request. Matches any path when empty
+	// +optional
+	Paths []string `json:"paths,omitempty"`
+
+	// Methods of the request. Matches any method when empty
+	// +optional
+	Methods []string `json:"methods,omitempty"`
+}
+
+// ProfileNamespace is an additional namespace managed by a profile. The
+// namespace shares the contributors of the profile namespace.
+type ProfileNamespace struct {
+	// Name of the namespace
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// Labels added to the namespace
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// ResourceQuotaSpec that will be applied to the namespace. Defaults to the
+	// profile resource quota spec
+	// +optional
+	ResourceQuotaSpec *corev1.ResourceQuotaSpec `json:"resourceQuotaSpec,omitempty"`
 }
 
 // ProfileSpec defines the desired state of Profile
@@ -29,10 +127,61 @@ type ProfileSpec struct {
 	// The profile owner
 	Owner rbacv1.Subject `json:"owner"`
 
+	// Namespace managed by the profile. Defaults to the profile name. The
+	// namespace can't be changed after the profile is created, which is
+	// enforced by the profile validating webhook.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Namespaces are additional namespaces managed by the profile. Each
+	// namespace gets its own resource quota and policies, and the contributors
+	// of the profile namespace are copied into it.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Namespaces []ProfileNamespace `json:"namespaces,omitempty"`
+
+	// TemplateRef selects the ProfileTemplate the profile is created from.
+	// Settings in the profile take precedence over the template.
+	// +optional
+	TemplateRef *corev1.LocalObjectReference `json:"templateRef,omitempty"`
+
+	// Plugins extend the profile with access to external resources
+	// +optional
+	Plugins []Plugin `json:"plugins,omitempty"`
+
 	// ResourceQuotaSpec that will be applied to target namespace
 	ResourceQuotaSpec *corev1.ResourceQuotaSpec `json:"resourceQuotaSpec,omitempty"`
+
+	// ResourceQuotas are additional named ResourceQuotas applied to each
+	// profile namespace. Quotas that are removed from the list are deleted.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	ResourceQuotas []ProfileResourceQuota `json:"resourceQuotas,omitempty"`
+
+	// Istio configures additional access to and from the profile namespaces
+	// +optional
+	Istio *ProfileIstio `json:"istio,omitempty"`
+
+	// LimitRange that will be applied to the profile namespaces. It sets the
+	// default resource requests and limits for pods that don't specify them.
+	// +optional
+	LimitRange *corev1.LimitRangeSpec `json:"limitRange,omitempty"`
+
+	// DeletionPolicy determines whether the profile namespace is deleted or
+	// orphaned when the profile is deleted
+	// +kubebuilder:default=Delete
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
 }
 
+// AnnotationDeletionProtection blocks deletion of a profile while it is set
+// to "true"
+const AnnotationDeletionProtection = "profiles.kubeflow.org/deletion-protection"
+
 const (
 	ProfileSucceed = "Successful"
 	ProfileFailed  = "Failed"
@@ -41,18 +190,91 @@ const (
 
 // ProfileStatus defines the observed state of Profile
 type ProfileStatus struct {
-	// Conditions
-	Conditions []ProfileCondition `json:"conditions,omitempty"`
+	// Conditions of the profile and each of its managed resources
+	xpv1.ConditionedStatus `json:",inline"`
+
+	// ObservedGeneration is the metadata.generation the conditions were set from
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Namespace is the namespace managed by the profile
+	Namespace string `json:"namespace,omitempty"`
+
+	// Namespaces are all of the namespaces managed by the profile, starting
+	// with the profile namespace
+	Namespaces []string `json:"namespaces,omitempty"`
 
 	// Contributors is a list of current contributors
-	Contributors []corev1.LocalObjectReference `json:"contributors,omitempty"`
+	Contributors []ProfileContributor `json:"contributors,omitempty"`
+
+	// Plugins is the observed state of each plugin in the profile spec
+	Plugins []PluginStatus `json:"plugins,omitempty"`
+
+	// TemplateObjects are the objects created from the profile template
+	TemplateObjects []corev1.ObjectReference `json:"templateObjects,omitempty"`
+
+	// ResourceQuotas is the observed usage of each resource quota managed by
+	// the profile
+	ResourceQuotas []ResourceQuotaStatus `json:"resourceQuotas,omitempty"`
+}
+
+// ResourceQuotaStatus is the observed usage of a resource quota managed by a
+// profile
+type ResourceQuotaStatus struct {
+	// Name of the ResourceQuota
+	Name string `json:"name"`
+
+	// Namespace of the ResourceQuota
+	Namespace string `json:"namespace"`
+
+	// Hard is the enforced hard limit for each resource
+	Hard corev1.ResourceList `json:"hard,omitempty"`
+
+	// Used is the current usage of each resource
+	Used corev1.ResourceList `json:"used,omitempty"`
+
+	// Utilization is the percentage of the hard limit used for each resource
+	Utilization map[corev1.ResourceName]int64 `json:"utilization,omitempty"`
+}
+
+// PluginStatus is the observed state of a profile plugin
+type PluginStatus struct {
+	// Kind of the plugin
+	Kind string `json:"kind"`
+
+	// Ready is True when the plugin was applied to the profile
+	Ready corev1.ConditionStatus `json:"ready,omitempty"`
+
+	// Message containing details about why the plugin is not ready
+	Message string `json:"message,omitempty"`
+}
+
+// ProfileContributor is the observed state of a contributor in the
+// profile namespace
+type ProfileContributor struct {
+	// Name of the Contributor
+	Name string `json:"name"`
+
+	// User is the name of the user granted access by the Contributor
+	User string `json:"user,omitempty"`
+
+	// Role of the contributor in the profile
+	Role string `json:"role,omitempty"`
+
+	// Ready is the status of the Contributor Ready condition
+	Ready corev1.ConditionStatus `json:"ready,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:storageversion
 // +kubebuilder:resource:path=profiles,scope=Cluster
+// +kubebuilder:printcolumn:name="NAMESPACE",type="string",JSONPath=".status.namespace"
 // +kubebuilder:printcolumn:name="OWNER",type="string",JSONPath=".spec.owner.name"
 // +kubebuilder:printcolumn:name="KIND",type="string",JSONPath=".spec.owner.kind"
+// +kubebuilder:printcolumn:name="CONTRIBUTORS",type="string",JSONPath=".status.contributors[*].name"
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
 
 // Profile is the Schema for the profiles API
 type Profile struct {
@@ -63,6 +285,50 @@ type Profile struct {
 	Status ProfileStatus `json:"status,omitempty"`
 }
 
+// TargetNamespace returns the namespace managed by the profile. The namespace
+// recorded in the profile status takes precedence over the spec so that a
+// profile keeps managing the namespace it created.
+func (in *Profile) TargetNamespace() string {
+	if in.Status.Namespace != "" {
+		return in.Status.Namespace
+	}
+	if in.Spec.Namespace != "" {
+		return in.Spec.Namespace
+	}
+	return in.Name
+}
+
+// TargetNamespaces returns every namespace managed by the profile, starting
+// with the profile namespace
+func (in *Profile) TargetNamespaces() []ProfileNamespace {
+	namespace := in.TargetNamespace()
+	namespaces := []ProfileNamespace{{Name: namespace}}
+	for _, ns := range in.Spec.Namespaces {
+		if ns.Name == namespace {
+			continue
+		}
+		namespaces = append(namespaces, ns)
+	}
+	return namespaces
+}
+
+// GetControllingProfile returns the reference to the Profile that controls
+// obj, or nil when obj isn't controlled by a Profile
+func GetControllingProfile(obj metav1.Object) *metav1.OwnerReference {
+	ref := metav1.GetControllerOf(obj)
+	if ref == nil || !IsProfileRef(*ref) {
+		return nil
+	}
+	return ref
+}
+
+// IsProfileRef returns true when ref refers to a Profile. The version isn't
+// compared, since a Profile can be referenced by any of its served versions
+func IsProfileRef(ref metav1.OwnerReference) bool {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	return err == nil && gv.Group == Group && ref.Kind == ProfileKind
+}
+
 // +kubebuilder:object:root=true
 
 // ProfileList contains a list of Profile
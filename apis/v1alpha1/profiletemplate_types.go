@@ -0,0 +1,67 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ProfileTemplateSpec defines the setup shared by the profiles that select
+// the template. Settings in a profile take precedence over the template.
+type ProfileTemplateSpec struct {
+	// Labels added to each profile namespace
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations added to each profile namespace
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// ResourceQuotaSpec applied to each profile namespace when the profile
+	// doesn't specify one
+	// +optional
+	ResourceQuotaSpec *corev1.ResourceQuotaSpec `json:"resourceQuotaSpec,omitempty"`
+
+	// LimitRangeSpec applied to each profile namespace
+	// +optional
+	LimitRangeSpec *corev1.LimitRangeSpec `json:"limitRangeSpec,omitempty"`
+
+	// Objects created in each profile namespace. Objects must be namespaced,
+	// and the namespace of each object is set to the profile namespace.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:EmbeddedResource
+	// +optional
+	Objects []runtime.RawExtension `json:"objects,omitempty"`
+}
+
+// ProfileTemplate is the Schema for the profiletemplates API
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=profiletemplates,scope=Cluster
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+type ProfileTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ProfileTemplateSpec `json:"spec,omitempty"`
+}
+
+// ProfileTemplateList contains a list of ProfileTemplates
+// +kubebuilder:object:root=true
+type ProfileTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ProfileTemplate `json:"items"`
+}
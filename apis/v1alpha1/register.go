@@ -43,6 +43,9 @@ var (
 
 	// ProfileKind is the string representation of profile kind
 	ProfileKind = reflect.TypeOf(&Profile{}).Elem().Name()
+
+	// ProfileTemplateKind is the string representation of profile template kind
+	ProfileTemplateKind = reflect.TypeOf(&ProfileTemplate{}).Elem().Name()
 )
 
 func init() {
@@ -51,5 +54,7 @@ func init() {
 		&ContributorList{},
 		&Profile{},
 		&ProfileList{},
+		&ProfileTemplate{},
+		&ProfileTemplateList{},
 	)
 }
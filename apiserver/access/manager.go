@@ -1,6 +1,7 @@
 package access
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/base32"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/johnhoman/kubeflow-profile-manager/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -98,7 +100,7 @@ func (m *manager) RemoveProfile(c *gin.Context) {
 	}
 
 	contributorList := &v1alpha1.ContributorList{}
-	if err := m.client.List(c, contributorList, client.InNamespace(p.Name)); err != nil {
+	if err := m.client.List(c, contributorList, client.InNamespace(p.TargetNamespace())); err != nil {
 		_ = c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
@@ -147,7 +149,16 @@ func (m *manager) AddContributor(c *gin.Context) {
 		"owner.kubeflow.org/id":         md5Sum(binding.User.Name),
 		"contributor.kubeflow.org/role": binding.RoleRef.Name,
 	}
-	contributor.Namespace = binding.ReferredNamespace
+	profile, err := m.profileForNamespace(c, binding.ReferredNamespace)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if apierrors.IsNotFound(err) {
+			code = http.StatusNotFound
+		}
+		_ = c.AbortWithError(code, err)
+		return
+	}
+	contributor.Namespace = profile.TargetNamespace()
 	contributor.Spec = v1alpha1.ContributorSpec{
 		Name: binding.User.Name,
 		Role: v1alpha1.ContributorRoleContributor,
@@ -224,14 +235,18 @@ func (m *manager) RemoveContributor(c *gin.Context) {
 		return
 	}
 
-	profile := &v1alpha1.Profile{}
-	if err := m.client.Get(c, client.ObjectKey{Name: binding.ReferredNamespace}, profile); err != nil {
-		_ = c.AbortWithError(http.StatusInternalServerError, err)
+	profile, err := m.profileForNamespace(c, binding.ReferredNamespace)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if apierrors.IsNotFound(err) {
+			code = http.StatusNotFound
+		}
+		_ = c.AbortWithError(code, err)
 		return
 	}
 
 	contributorList := &v1alpha1.ContributorList{}
-	if err := m.client.List(c, contributorList, client.InNamespace(binding.ReferredNamespace)); err != nil {
+	if err := m.client.List(c, contributorList, client.InNamespace(profile.TargetNamespace())); err != nil {
 		_ = c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
@@ -255,7 +270,7 @@ func (m *manager) RemoveContributor(c *gin.Context) {
 	if err := m.client.DeleteAllOf(c,
 		&v1alpha1.Contributor{},
 		client.MatchingLabels{"owner.kubeflow.org/id": md5Sum(binding.User.Name)},
-		client.InNamespace(binding.ReferredNamespace),
+		client.InNamespace(profile.TargetNamespace()),
 	); err != nil {
 		_ = c.AbortWithError(http.StatusInternalServerError, err)
 		return
@@ -265,6 +280,33 @@ func (m *manager) RemoveContributor(c *gin.Context) {
 	return
 }
 
+// profileForNamespace returns the profile that manages namespace, which is
+// the controller of the namespace. Contributors are shared by every namespace
+// of a profile and are managed in the profile namespace.
+func (m *manager) profileForNamespace(ctx context.Context, namespace string) (*v1alpha1.Profile, error) {
+	notFound := apierrors.NewNotFound(v1alpha1.GroupVersion.WithResource("profiles").GroupResource(), namespace)
+
+	ns := &corev1.Namespace{}
+	if err := m.client.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, notFound
+		}
+		return nil, err
+	}
+	ref := v1alpha1.GetControllingProfile(ns)
+	if ref == nil {
+		return nil, notFound
+	}
+	profile := &v1alpha1.Profile{}
+	if err := m.client.Get(ctx, client.ObjectKey{Name: ref.Name}, profile); err != nil {
+		return nil, err
+	}
+	if profile.UID != ref.UID {
+		return nil, notFound
+	}
+	return profile, nil
+}
+
 func (m *manager) ListAdmins(c *gin.Context) {
 	user := c.Query("user")
 	if user == "" {
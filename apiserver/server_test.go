@@ -12,11 +12,13 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/johnhoman/kubeflow-profile-manager/apis/v1alpha1"
 	"github.com/johnhoman/kubeflow-profile-manager/apiserver"
+	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/json"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
@@ -148,6 +150,94 @@ func TestServer_RemoveProfile(t *testing.T) {
 	}
 }
 
+func TestServer_AddContributor(t *testing.T) {
+
+	profile := &v1alpha1.Profile{
+		ObjectMeta: metav1.ObjectMeta{Name: "starlord", UID: "1234"},
+		Spec: v1alpha1.ProfileSpec{
+			Owner:      rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+			Namespaces: []v1alpha1.ProfileNamespace{{Name: "starlord-dev"}},
+		},
+	}
+	namespace := func(name string, owner *v1alpha1.Profile) *corev1.Namespace {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if owner != nil {
+			ns.OwnerReferences = []metav1.OwnerReference{{
+				APIVersion: v1alpha1.GroupVersion.String(),
+				Kind:       "Profile",
+				Name:       owner.Name,
+				UID:        owner.UID,
+				Controller: pointer.Bool(true),
+			}}
+		}
+		return ns
+	}
+	body := func(namespace string) Body {
+		return Body{
+			"user":              map[string]any{"kind": "User", "name": "gamora@guardians.net"},
+			"referredNamespace": namespace,
+			"roleRef":           map[string]any{"kind": "ClusterRole", "name": "edit"},
+		}
+	}
+
+	cases := map[string]struct {
+		initObjs []client.Object
+		body     Body
+		code     int
+		want     client.ObjectKey
+	}{
+		"AddsTheContributorToTheProfileNamespace": {
+			initObjs: []client.Object{profile, namespace("starlord-dev", profile)},
+			body:     body("starlord-dev"),
+			code:     http.StatusOK,
+			want:     client.ObjectKey{Namespace: "starlord", Name: "gamora"},
+		},
+		"NotFoundWithoutAProfile": {
+			initObjs: []client.Object{namespace("starlord", nil)},
+			body:     body("starlord"),
+			code:     http.StatusNotFound,
+		},
+		"NotFoundWithoutANamespace": {
+			initObjs: []client.Object{profile},
+			body:     body("starlord"),
+			code:     http.StatusNotFound,
+		},
+	}
+
+	ctx := context.Background()
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	for name, subtest := range cases {
+		t.Run(name, func(t *testing.T) {
+
+			k8s := fake.NewClientBuilder().
+				WithObjects(subtest.initObjs...).
+				WithScheme(scheme.Scheme).
+				Build()
+
+			server := apiserver.NewServer(k8s, apiserver.Options{})
+
+			w := httptest.NewRecorder()
+			raw, err := json.Marshal(subtest.body)
+			qt.Assert(t, err, qt.IsNil)
+			req, err := http.NewRequest(http.MethodPost, "/v1/bindings", bytes.NewReader(raw))
+			qt.Assert(t, err, qt.IsNil)
+			server.ServeHTTP(w, req)
+
+			qt.Assert(t, w.Code, qt.Equals, subtest.code)
+
+			contributorList := &v1alpha1.ContributorList{}
+			qt.Assert(t, k8s.List(ctx, contributorList), qt.IsNil)
+			if subtest.code != http.StatusOK {
+				qt.Assert(t, contributorList.Items, qt.HasLen, 0)
+				return
+			}
+			qt.Assert(t, contributorList.Items, qt.HasLen, 1)
+			qt.Assert(t, client.ObjectKeyFromObject(&contributorList.Items[0]), qt.Equals, subtest.want)
+		})
+	}
+}
+
 type Body map[string]any
 
 func (b Body) Read(p []byte) (n int, err error) {
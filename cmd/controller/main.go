@@ -1,22 +1,39 @@
 package main
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
 	"github.com/alecthomas/kong"
-	"github.com/crossplane/crossplane-runtime/pkg/controller"
+	xpcontroller "github.com/crossplane/crossplane-runtime/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
 	"github.com/crossplane/crossplane-runtime/pkg/feature"
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/johnhoman/kubeflow-profile-manager/controller"
 	"github.com/johnhoman/kubeflow-profile-manager/controller/contributor"
 	"github.com/johnhoman/kubeflow-profile-manager/controller/features"
 	"github.com/johnhoman/kubeflow-profile-manager/controller/profile"
+	"github.com/johnhoman/kubeflow-profile-manager/webhook/certs"
+	contributorwebhook "github.com/johnhoman/kubeflow-profile-manager/webhook/contributor"
+	profilewebhook "github.com/johnhoman/kubeflow-profile-manager/webhook/profile"
+	"github.com/pkg/errors"
 	"go.uber.org/zap/zapcore"
+	istionetworking "istio.io/client-go/pkg/apis/networking/v1beta1"
 	istiosecurity "istio.io/client-go/pkg/apis/security/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
 
+	v1 "github.com/johnhoman/kubeflow-profile-manager/apis/v1"
 	"github.com/johnhoman/kubeflow-profile-manager/apis/v1alpha1"
 )
 
@@ -31,8 +48,44 @@ var CLI struct {
 
 	LeaderElect bool `name:"leader-elect" help:"enable leader election"`
 
-	EnabledIstio    bool `name:"enable-istio" help:"enable integration with Istio" default:"true"`
-	EnablePipelines bool `name:"enable-pipelines"`
+	EnableWebhooks     bool     `name:"enable-webhooks" help:"serve validating admission webhooks"`
+	WebhookPort        int      `name:"webhook-port" default:"9443" help:"port the webhook server listens on"`
+	WebhookCertDir     string   `name:"webhook-cert-dir" help:"directory containing the webhook serving certificate"`
+	ReservedNamespaces []string `name:"reserved-namespaces" help:"namespaces that profiles may not claim (defaults to the system namespaces)"`
+
+	ManageWebhookCerts             bool   `name:"manage-webhook-certs" default:"true" negatable:"" help:"generate and rotate the webhook serving certificate"`
+	WebhookSecretName              string `name:"webhook-secret-name" default:"profile-manager-webhook-cert" help:"secret the webhook certificates are stored in"`
+	WebhookServiceName             string `name:"webhook-service-name" default:"profile-manager-webhook" help:"service the webhook server is exposed through"`
+	ValidatingWebhookConfiguration string `name:"validating-webhook-configuration" default:"profile-manager-validating-webhook" help:"validating webhook configuration to inject the CA bundle into"`
+	Namespace                      string `name:"namespace" env:"POD_NAMESPACE" default:"kubeflow-system" help:"namespace the controller is running in"`
+
+	AWSOIDCProvider string `name:"aws-oidc-provider" help:"EKS cluster OIDC provider used in generated IAM role trust policies"`
+
+	DefaultProfileTemplate string `name:"default-profile-template" help:"ProfileTemplate used by profiles that don't select a template"`
+
+	DefaultContainerRequests map[string]string `name:"default-container-requests" help:"default container resource requests in profile namespaces (e.g. cpu=100m;memory=256Mi)"`
+	DefaultContainerLimits   map[string]string `name:"default-container-limits" help:"default container resource limits in profile namespaces (e.g. cpu=1;memory=1Gi)"`
+
+	QuotaWarningThreshold int64 `name:"quota-warning-threshold" default:"90" help:"percentage of a resource quota a profile can use before the QuotaWarning condition is set"`
+
+	IstioTrustDomain                  string   `name:"istio-trust-domain" default:"cluster.local" help:"trust domain of the Istio mesh"`
+	IstioIngressGatewayServiceAccount string   `name:"istio-ingress-gateway-service-account" default:"istio-system/istio-ingressgateway-service-account" help:"namespace/name of the Istio ingress gateway service account"`
+	ClusterLocalGatewayServiceAccount string   `name:"istio-cluster-local-gateway-service-account" default:"istio-system/cluster-local-gateway-service-account" help:"namespace/name of the Istio cluster-local gateway service account"`
+	KnativeServingNamespace           string   `name:"knative-serving-namespace" default:"knative-serving" help:"namespace of the Knative serving system components"`
+	NotebookControllerServiceAccount  string   `name:"notebook-controller-service-account" default:"kubeflow/notebook-controller-service-account" help:"namespace/name of the notebook controller service account"`
+	IstioProbePaths                   []string `name:"istio-probe-paths" default:"/healthz,/metrics,/wait-for-drain" help:"workload paths accessible to system probes in profile namespaces"`
+
+	EnabledIstio            bool `name:"enable-istio" help:"enable integration with Istio" default:"true"`
+	EnablePipelines         bool `name:"enable-pipelines"`
+	EnableNamespaceAdoption bool `name:"enable-namespace-adoption" help:"allow profiles to adopt existing namespaces"`
+	EnableKServe            bool `name:"enable-kserve" help:"enable integration with KServe"`
+	EnableStrictMTLS        bool `name:"enable-strict-mtls" help:"require mutual TLS for traffic into profile namespaces"`
+	EnableSidecarEgress     bool `name:"enable-sidecar-egress" help:"limit the hosts workloads in profile namespaces can reach"`
+	EnableNetworkPolicy     bool `name:"enable-network-policy" help:"deny ingress into profile namespaces from other namespaces"`
+
+	SidecarEgressHosts []string `name:"sidecar-egress-hosts" default:"./*,istio-system/*,kubeflow/*" help:"hosts workloads in profile namespaces can reach when sidecar egress is enabled, in namespace/dnsName format"`
+
+	NetworkPolicyAllowedNamespaces []string `name:"network-policy-allowed-namespaces" default:"kubeflow,istio-system,knative-serving,knative-eventing" help:"namespaces allowed ingress into profile namespaces when network policies are enabled"`
 }
 
 func main() {
@@ -42,7 +95,10 @@ func main() {
 		kong.DefaultEnvars(""),
 	)
 	ctx.FatalIfErrorf(v1alpha1.AddToScheme(scheme.Scheme))
+	ctx.FatalIfErrorf(v1.AddToScheme(scheme.Scheme))
 	ctx.FatalIfErrorf(istiosecurity.AddToScheme(scheme.Scheme))
+	ctx.FatalIfErrorf(istionetworking.AddToScheme(scheme.Scheme))
+	ctx.FatalIfErrorf(apiextensionsv1.AddToScheme(scheme.Scheme))
 
 	flags := &feature.Flags{}
 	if CLI.EnabledIstio {
@@ -51,32 +107,149 @@ func main() {
 	if CLI.EnablePipelines {
 		flags.Enable(features.Pipelines)
 	}
+	if CLI.EnableNamespaceAdoption {
+		flags.Enable(features.NamespaceAdoption)
+	}
+	if CLI.EnableKServe {
+		flags.Enable(features.KServe)
+	}
+	if CLI.EnableStrictMTLS {
+		flags.Enable(features.StrictMTLS)
+	}
+	if CLI.EnableSidecarEgress {
+		flags.Enable(features.SidecarEgress)
+	}
+	if CLI.EnableNetworkPolicy {
+		flags.Enable(features.NetworkPolicy)
+	}
 
 	zapLogger := zap.New(zap.UseDevMode(CLI.Debug), func(o *zap.Options) {
 		o.TimeEncoder = zapcore.RFC3339TimeEncoder
 	})
 
-	mgr, err := manager.New(config.GetConfigOrDie(), manager.Options{
+	if CLI.WebhookCertDir == "" {
+		CLI.WebhookCertDir = filepath.Join(os.TempDir(), "k8s-webhook-server", "serving-certs")
+	}
+
+	cfg := config.GetConfigOrDie()
+	mgr, err := manager.New(cfg, manager.Options{
 		Scheme:                 scheme.Scheme,
 		Logger:                 zapLogger,
 		LeaderElectionID:       "manager.profiles.kubeflow.org",
 		LeaderElection:         CLI.LeaderElect,
 		HealthProbeBindAddress: CLI.HealthProbeBindAddress,
 		MetricsBindAddress:     CLI.MetricsBindAddress,
+		Port:                   CLI.WebhookPort,
+		CertDir:                CLI.WebhookCertDir,
 	})
 	ctx.FatalIfErrorf(err, "unable to create manager")
 
 	opts := controller.Options{
-		Features: flags,
-		Logger:   logging.NewLogrLogger(zapLogger),
+		Options: xpcontroller.Options{
+			Features: flags,
+			Logger:   logging.NewLogrLogger(zapLogger),
+		},
+		Recorder:        event.NewAPIRecorder(mgr.GetEventRecorderFor("kubeflow-profile-manager")),
+		AWSOIDCProvider: CLI.AWSOIDCProvider,
+	}
+
+	ingressGateway, err := serviceAccount(CLI.IstioIngressGatewayServiceAccount)
+	ctx.FatalIfErrorf(err, "invalid istio ingress gateway service account")
+	clusterLocalGateway, err := serviceAccount(CLI.ClusterLocalGatewayServiceAccount)
+	ctx.FatalIfErrorf(err, "invalid istio cluster-local gateway service account")
+	notebookController, err := serviceAccount(CLI.NotebookControllerServiceAccount)
+	ctx.FatalIfErrorf(err, "invalid notebook controller service account")
+	opts.Istio = &controller.IstioConfig{
+		TrustDomain:             CLI.IstioTrustDomain,
+		IngressGateway:          ingressGateway,
+		ClusterLocalGateway:     clusterLocalGateway,
+		KnativeServingNamespace: CLI.KnativeServingNamespace,
+		NotebookController:      notebookController,
+		ProbePaths:              CLI.IstioProbePaths,
 	}
 
-	ctx.FatalIfErrorf(profile.Setup(mgr, opts), "failed to setup profile controller")
+	profileOpts := []profile.ReconcilerOption{
+		profile.WithDefaultTemplate(CLI.DefaultProfileTemplate),
+		profile.WithQuotaWarningThreshold(CLI.QuotaWarningThreshold),
+		profile.WithNetworkPolicyAllowedNamespaces(CLI.NetworkPolicyAllowedNamespaces...),
+		profile.WithSidecarEgressHosts(CLI.SidecarEgressHosts...),
+	}
+	if len(CLI.DefaultContainerRequests) > 0 || len(CLI.DefaultContainerLimits) > 0 {
+		requests, err := resourceList(CLI.DefaultContainerRequests)
+		ctx.FatalIfErrorf(err, "invalid default container requests")
+		limits, err := resourceList(CLI.DefaultContainerLimits)
+		ctx.FatalIfErrorf(err, "invalid default container limits")
+		profileOpts = append(profileOpts, profile.WithDefaultLimitRangeSpec(corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{{
+				Type:           corev1.LimitTypeContainer,
+				Default:        limits,
+				DefaultRequest: requests,
+			}},
+		}))
+	}
+	ctx.FatalIfErrorf(profile.Setup(mgr, opts, profileOpts...), "failed to setup profile controller")
 	ctx.FatalIfErrorf(contributor.Setup(mgr, opts,
 		contributor.WithUserIDPrefix(CLI.UserIDPrefix),
 		contributor.WithUserIDHeader(CLI.UserIDHeader)),
 		"failed to setup profile controller")
+	ctx.FatalIfErrorf(contributor.SetupPolicies(mgr, opts,
+		contributor.WithPolicyUserIDPrefix(CLI.UserIDPrefix),
+		contributor.WithPolicyUserIDHeader(CLI.UserIDHeader)),
+		"failed to setup contributor policy controller")
+
+	if CLI.EnableWebhooks {
+		webhookOpts := make([]profilewebhook.ValidatorOption, 0)
+		if len(CLI.ReservedNamespaces) > 0 {
+			webhookOpts = append(webhookOpts, profilewebhook.WithReservedNamespaces(CLI.ReservedNamespaces...))
+		}
+		// the profile webhook also serves the v1 conversion webhook because
+		// v1 is registered in the scheme
+		ctx.FatalIfErrorf(profilewebhook.Setup(mgr, opts, webhookOpts...), "failed to setup profile webhook")
+		ctx.FatalIfErrorf(contributorwebhook.Setup(mgr, opts), "failed to setup contributor webhook")
+
+		if CLI.ManageWebhookCerts {
+			// the manager cache isn't started yet, so the certificates are
+			// bootstrapped with a direct client before the webhook server starts
+			cli, err := client.New(cfg, client.Options{Scheme: scheme.Scheme})
+			ctx.FatalIfErrorf(err, "failed to create client")
+			rotator := certs.NewRotator(cli,
+				certs.WithLogger(opts.Logger.WithValues("runnable", "cert-rotator")),
+				certs.WithSecret(CLI.Namespace, CLI.WebhookSecretName),
+				certs.WithService(CLI.Namespace, CLI.WebhookServiceName),
+				certs.WithCertDir(CLI.WebhookCertDir),
+				certs.WithValidatingWebhookConfigurations(CLI.ValidatingWebhookConfiguration),
+				certs.WithConversionCRDs("profiles.kubeflow.org"),
+			)
+			ctx.FatalIfErrorf(rotator.Reconcile(context.Background()), "failed to bootstrap webhook certificates")
+			ctx.FatalIfErrorf(mgr.Add(rotator), "failed to add webhook certificate rotator")
+		}
+	}
 	ctx.FatalIfErrorf(mgr.AddHealthzCheck("healthz", healthz.Ping), "failed to add healthcheck")
 	ctx.FatalIfErrorf(mgr.AddReadyzCheck("readyz", healthz.Ping), "failed to add ready check")
 	ctx.FatalIfErrorf(mgr.Start(signals.SetupSignalHandler()), "unable to start controller manager")
 }
+
+// serviceAccount parses a service account in the form namespace/name
+func serviceAccount(value string) (controller.ServiceAccount, error) {
+	namespace, name, ok := strings.Cut(value, "/")
+	if !ok || namespace == "" || name == "" {
+		return controller.ServiceAccount{}, errors.Errorf("service account %q must be in the form namespace/name", value)
+	}
+	return controller.ServiceAccount{Namespace: namespace, Name: name}, nil
+}
+
+// resourceList parses resource quantities keyed by resource name
+func resourceList(values map[string]string) (corev1.ResourceList, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	list := make(corev1.ResourceList, len(values))
+	for name, value := range values {
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid quantity for %s", name)
+		}
+		list[corev1.ResourceName(name)] = q
+	}
+	return list, nil
+}
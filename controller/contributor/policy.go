@@ -0,0 +1,326 @@
+package contributor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/pkg/errors"
+	"istio.io/api/security/v1beta1"
+	v1beta12 "istio.io/api/type/v1beta1"
+	istiosecurity "istio.io/client-go/pkg/apis/security/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/johnhoman/kubeflow-profile-manager/apis/v1alpha1"
+	"github.com/johnhoman/kubeflow-profile-manager/controller"
+	"github.com/johnhoman/kubeflow-profile-manager/controller/features"
+	"github.com/johnhoman/kubeflow-profile-manager/controller/manager"
+)
+
+const (
+	errReadNamespace                = "failed to read namespace"
+	errReadPolicyOwner              = "failed to read the profile of the namespace"
+	errListContributors             = "failed to list contributors"
+	errListAuthorizationPolicies    = "failed to list authorization policies"
+	errDeleteAuthorizationPolicy    = "failed to delete authorization policy"
+	errFmtSetOwnerRef               = "failed to set owner reference on %s"
+	errFmtReconcileNamespacedPolicy = "failed to reconcile authorization policy %s"
+
+	// PublicPolicyName is the AuthorizationPolicy that allows every contributor
+	// in a namespace to access the workloads labeled kubeflow.org/visibility=public
+	PublicPolicyName = "kf-contributors-public"
+	// PrivatePolicyName is the AuthorizationPolicy that allows every contributor
+	// in a namespace to access the workloads in the namespace
+	PrivatePolicyName = "kf-contributors-private"
+)
+
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=kubeflow.org,resources=profiles,verbs=get;list;watch
+
+// SetupPolicies sets up the PolicyReconciler, which renders the
+// AuthorizationPolicies for all of the contributors in a namespace. The
+// AuthorizationPolicies are only rendered when Istio is enabled.
+func SetupPolicies(mgr ctrl.Manager, o controller.Options, opts ...PolicyReconcilerOption) error {
+	if !o.Features.Enabled(features.Istio) {
+		return nil
+	}
+
+	name := "kubeflow.org/contributor-policy-manager"
+
+	opts = append(opts, WithPolicyLogger(o.Logger.WithValues("controller", name)))
+	if o.Istio != nil {
+		opts = append(opts, WithPolicyIstioConfig(*o.Istio))
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		WithOptions(o.ForControllerRuntime()).
+		For(&corev1.Namespace{}).
+		Watches(
+			&source.Kind{Type: &v1alpha1.Contributor{}},
+			handler.EnqueueRequestsFromMapFunc(namespaceOf),
+		).
+		Watches(
+			&source.Kind{Type: &istiosecurity.AuthorizationPolicy{}},
+			handler.EnqueueRequestsFromMapFunc(namespaceOf),
+		).
+		Complete(NewPolicyReconciler(mgr, opts...))
+}
+
+// namespaceOf maps an object to the namespace it is in
+func namespaceOf(o client.Object) []ctrl.Request {
+	return []ctrl.Request{{NamespacedName: client.ObjectKey{Name: o.GetNamespace()}}}
+}
+
+type PolicyReconcilerOption func(r *PolicyReconciler)
+
+func WithPolicyUserIDPrefix(prefix string) PolicyReconcilerOption {
+	return func(r *PolicyReconciler) {
+		r.userIDPrefix = prefix
+	}
+}
+
+func WithPolicyUserIDHeader(header string) PolicyReconcilerOption {
+	return func(r *PolicyReconciler) {
+		r.userIDHeader = header
+	}
+}
+
+// WithPolicyIstioConfig sets the principals used in the contributor
+// AuthorizationPolicies
+func WithPolicyIstioConfig(config controller.IstioConfig) PolicyReconcilerOption {
+	return func(r *PolicyReconciler) {
+		r.istioConfig = config
+	}
+}
+
+func WithPolicyLogger(logger logging.Logger) PolicyReconcilerOption {
+	return func(r *PolicyReconciler) {
+		r.logger = logger
+	}
+}
+
+func NewPolicyReconciler(mgr manager.Manager, opts ...PolicyReconcilerOption) *PolicyReconciler {
+	r := &PolicyReconciler{
+		client:       mgr.GetClient(),
+		logger:       logging.NewNopLogger(),
+		userIDHeader: "kubeflow-userid",
+		istioConfig:  controller.DefaultIstioConfig(),
+	}
+	for _, f := range opts {
+		f(r)
+	}
+	return r
+}
+
+// PolicyReconciler reconciles the AuthorizationPolicies for all of the
+// contributors in a namespace. A single public policy allows every contributor
+// to access the public workloads, and a single private policy allows every
+// contributor to access the workloads in the namespace. The
+// AuthorizationPolicies previously created for each contributor are deleted.
+//
+// The policies are owned by the profile of the namespace, or by the namespace
+// when it isn't managed by a profile. Their readiness is reported on each
+// contributor by the contributor Reconciler.
+type PolicyReconciler struct {
+	client client.Client
+	logger logging.Logger
+
+	istioConfig controller.IstioConfig
+
+	// user id
+	userIDPrefix string
+	userIDHeader string
+}
+
+func (r *PolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+
+	namespace := &corev1.Namespace{}
+	if err := r.client.Get(ctx, req.NamespacedName, namespace); err != nil {
+		return ctrl.Result{}, errors.Wrap(client.IgnoreNotFound(err), errReadNamespace)
+	}
+	if !namespace.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	contributorList := &v1alpha1.ContributorList{}
+	if err := r.client.List(ctx, contributorList, client.InNamespace(namespace.Name)); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, errListContributors)
+	}
+	contributors := make([]v1alpha1.Contributor, 0, len(contributorList.Items))
+	for _, contributor := range contributorList.Items {
+		if contributor.DeletionTimestamp.IsZero() {
+			contributors = append(contributors, contributor)
+		}
+	}
+
+	if len(contributors) > 0 {
+		owner, err := r.policyOwner(ctx, namespace)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.reconcilePublicPolicy(ctx, owner, namespace.Name, contributors); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.reconcilePrivatePolicy(ctx, owner, namespace.Name, contributors); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	policyList := &istiosecurity.AuthorizationPolicyList{}
+	if err := r.client.List(ctx, policyList, client.InNamespace(namespace.Name)); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, errListAuthorizationPolicies)
+	}
+	for _, policy := range policyList.Items {
+		if !r.stale(policy, len(contributors) > 0) {
+			continue
+		}
+		r.logger.Debug("deleting authorization policy", "namespace", policy.Namespace, "name", policy.Name)
+		if err := r.client.Delete(ctx, policy); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, errors.Wrap(err, errDeleteAuthorizationPolicy)
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+// policyOwner returns the owner of the namespace policies, which is the
+// profile that controls the namespace, or the namespace itself
+func (r *PolicyReconciler) policyOwner(ctx context.Context, namespace *corev1.Namespace) (client.Object, error) {
+	ref := v1alpha1.GetControllingProfile(namespace)
+	if ref == nil {
+		return namespace, nil
+	}
+	profile := &v1alpha1.Profile{}
+	if err := r.client.Get(ctx, client.ObjectKey{Name: ref.Name}, profile); err != nil {
+		return nil, errors.Wrap(err, errReadPolicyOwner)
+	}
+	if profile.UID != ref.UID {
+		return namespace, nil
+	}
+	return profile, nil
+}
+
+// stale returns true for AuthorizationPolicies that should be deleted. These
+// are the policies created for a single contributor, and the namespace
+// policies once the namespace has no contributors
+func (r *PolicyReconciler) stale(policy *istiosecurity.AuthorizationPolicy, rendered bool) bool {
+	if policy.GetLabels()["app.kubernetes.io/part-of"] == "kubeflow-profile" {
+		if policy.Name == PublicPolicyName || policy.Name == PrivatePolicyName {
+			return !rendered
+		}
+	}
+	ref := metav1.GetControllerOf(policy)
+	if ref == nil || ref.Kind != "Contributor" || ref.APIVersion != v1alpha1.GroupVersion.String() {
+		return false
+	}
+	return policy.Name == ref.Name+"-public" || policy.Name == ref.Name+"-private"
+}
+
+// reconcilePublicPolicy creates or updates the AuthorizationPolicy that allows
+// the contributors in a namespace to access the public workloads through the
+// ingress gateway
+func (r *PolicyReconciler) reconcilePublicPolicy(ctx context.Context, owner client.Object, namespace string, contributors []v1alpha1.Contributor) error {
+
+	users := sets.NewString()
+	for _, contributor := range contributors {
+		users.Insert(r.userIDPrefix + contributor.Spec.Name)
+	}
+
+	public := &istiosecurity.AuthorizationPolicy{}
+	public.SetName(PublicPolicyName)
+	public.SetNamespace(namespace)
+	_, err := controllerutil.CreateOrPatch(ctx, r.client, public, func() error {
+		if err := controllerutil.SetOwnerReference(owner, public, r.client.Scheme()); err != nil {
+			return errors.Wrapf(err, errFmtSetOwnerRef, "AuthorizationPolicy")
+		}
+		addLabel(public, "app.kubernetes.io/part-of", "kubeflow-profile")
+		public.Spec = v1beta1.AuthorizationPolicy{
+			Action: v1beta1.AuthorizationPolicy_ALLOW,
+			Rules: []*v1beta1.Rule{{
+				When: []*v1beta1.Condition{{
+					Key:    fmt.Sprintf("request.headers[%v]", r.userIDHeader),
+					Values: users.List(),
+				}},
+				From: []*v1beta1.Rule_From{{
+					Source: &v1beta1.Source{
+						Principals: []string{r.istioConfig.IngressGatewayPrincipal()},
+					},
+				}},
+			}},
+			Selector: &v1beta12.WorkloadSelector{
+				MatchLabels: map[string]string{
+					"kubeflow.org/visibility": "public",
+				},
+			},
+		}
+		return nil
+	})
+	return errors.Wrapf(err, errFmtReconcileNamespacedPolicy, PublicPolicyName)
+}
+
+// reconcilePrivatePolicy creates or updates the AuthorizationPolicy that allows
+// the contributors in a namespace to access the workloads in the namespace. The
+// policy has a rule for each user, so that a user is only accepted through the
+// ingress gateway or the ServiceAccount of one of its contributors. The policy
+// has no selector and applies to every workload in the namespace
+func (r *PolicyReconciler) reconcilePrivatePolicy(ctx context.Context, owner client.Object, namespace string, contributors []v1alpha1.Contributor) error {
+
+	byUser := make(map[string][]v1alpha1.Contributor)
+	for _, contributor := range contributors {
+		byUser[contributor.Spec.Name] = append(byUser[contributor.Spec.Name], contributor)
+	}
+	rules := make([]*v1beta1.Rule, 0, len(byUser))
+	for _, user := range sortedKeys(byUser) {
+		serviceAccounts := sets.NewString()
+		for _, contributor := range byUser[user] {
+			serviceAccounts.Insert(r.istioConfig.Principal(controller.ServiceAccount{Namespace: contributor.Namespace, Name: contributor.Name}))
+		}
+		rules = append(rules, &v1beta1.Rule{
+			When: []*v1beta1.Condition{{
+				Key:    fmt.Sprintf("request.headers[%v]", r.userIDHeader),
+				Values: []string{r.userIDPrefix + user},
+			}},
+			From: []*v1beta1.Rule_From{{
+				Source: &v1beta1.Source{
+					Principals: append([]string{r.istioConfig.IngressGatewayPrincipal()}, serviceAccounts.List()...),
+				},
+			}},
+		})
+	}
+
+	private := &istiosecurity.AuthorizationPolicy{}
+	private.SetName(PrivatePolicyName)
+	private.SetNamespace(namespace)
+	_, err := controllerutil.CreateOrPatch(ctx, r.client, private, func() error {
+		if err := controllerutil.SetOwnerReference(owner, private, r.client.Scheme()); err != nil {
+			return errors.Wrapf(err, errFmtSetOwnerRef, "AuthorizationPolicy")
+		}
+		addLabel(private, "app.kubernetes.io/part-of", "kubeflow-profile")
+		private.Spec = v1beta1.AuthorizationPolicy{
+			Action: v1beta1.AuthorizationPolicy_ALLOW,
+			Rules:  rules,
+		}
+		return nil
+	})
+	return errors.Wrapf(err, errFmtReconcileNamespacedPolicy, PrivatePolicyName)
+}
+
+func sortedKeys(m map[string][]v1alpha1.Contributor) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var _ reconcile.Reconciler = &PolicyReconciler{}
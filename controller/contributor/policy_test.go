@@ -0,0 +1,302 @@
+package contributor
+
+import (
+	"context"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/johnhoman/kubeflow-profile-manager/apis/v1alpha1"
+	"github.com/johnhoman/kubeflow-profile-manager/controller"
+	"github.com/johnhoman/kubeflow-profile-manager/controller/manager"
+	"istio.io/api/security/v1beta1"
+	v1beta12 "istio.io/api/type/v1beta1"
+	istiosecurity "istio.io/client-go/pkg/apis/security/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPolicyReconciler_Reconcile(t *testing.T) {
+	contributors := []client.Object{
+		&v1alpha1.Contributor{
+			ObjectMeta: metav1.ObjectMeta{Name: "starlord", Namespace: "starlord"},
+			Spec:       v1alpha1.ContributorSpec{Role: "Owner", Name: "starlord@guardians.net"},
+		},
+		&v1alpha1.Contributor{
+			ObjectMeta: metav1.ObjectMeta{Name: "gamora", Namespace: "starlord"},
+			Spec:       v1alpha1.ContributorSpec{Role: "Contributor", Name: "gamora@guardians.net"},
+		},
+	}
+	profile := &v1alpha1.Profile{ObjectMeta: metav1.ObjectMeta{Name: "starlord", UID: "1234"}}
+	owned := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name: "starlord",
+		OwnerReferences: []metav1.OwnerReference{{
+			Name:               "starlord",
+			Kind:               "Profile",
+			APIVersion:         "kubeflow.org/v1alpha1",
+			UID:                "1234",
+			Controller:         pointer.Bool(true),
+			BlockOwnerDeletion: pointer.Bool(true),
+		}},
+	}}
+	ownedBy := func(kind, apiVersion, uid string) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{Name: "starlord", Kind: kind, APIVersion: apiVersion, UID: types.UID(uid)}}
+	}
+	legacy := func(contributor, name string) *istiosecurity.AuthorizationPolicy {
+		return &istiosecurity.AuthorizationPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "starlord",
+				OwnerReferences: []metav1.OwnerReference{{
+					Name:               contributor,
+					Kind:               "Contributor",
+					APIVersion:         "kubeflow.org/v1alpha1",
+					Controller:         pointer.Bool(true),
+					BlockOwnerDeletion: pointer.Bool(true),
+				}},
+			},
+		}
+	}
+	labeled := func(name string) *istiosecurity.AuthorizationPolicy {
+		return &istiosecurity.AuthorizationPolicy{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "starlord",
+			Labels:    map[string]string{"app.kubernetes.io/part-of": "kubeflow-profile"},
+		}}
+	}
+	gateway := "cluster.local/ns/istio-system/sa/istio-ingressgateway-service-account"
+
+	cases := map[string]struct {
+		opts      []PolicyReconcilerOption
+		namespace *corev1.Namespace
+		initObjs  []client.Object
+		want      []*istiosecurity.AuthorizationPolicy
+		deleted   []string
+	}{
+		"CreatesAPublicPolicyAndAPrivatePolicy": {
+			initObjs: contributors,
+			want: []*istiosecurity.AuthorizationPolicy{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            PublicPolicyName,
+					Namespace:       "starlord",
+					Labels:          map[string]string{"app.kubernetes.io/part-of": "kubeflow-profile"},
+					OwnerReferences: ownedBy("Profile", "kubeflow.org/v1alpha1", "1234"),
+				},
+				Spec: v1beta1.AuthorizationPolicy{
+					Action: v1beta1.AuthorizationPolicy_ALLOW,
+					Rules: []*v1beta1.Rule{{
+						From: []*v1beta1.Rule_From{{
+							Source: &v1beta1.Source{Principals: []string{gateway}},
+						}},
+						When: []*v1beta1.Condition{{
+							Key:    "request.headers[kubeflow-userid]",
+							Values: []string{"gamora@guardians.net", "starlord@guardians.net"},
+						}},
+					}},
+					Selector: &v1beta12.WorkloadSelector{
+						MatchLabels: map[string]string{"kubeflow.org/visibility": "public"},
+					},
+				},
+			}, {
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            PrivatePolicyName,
+					Namespace:       "starlord",
+					Labels:          map[string]string{"app.kubernetes.io/part-of": "kubeflow-profile"},
+					OwnerReferences: ownedBy("Profile", "kubeflow.org/v1alpha1", "1234"),
+				},
+				Spec: v1beta1.AuthorizationPolicy{
+					Action: v1beta1.AuthorizationPolicy_ALLOW,
+					Rules: []*v1beta1.Rule{{
+						From: []*v1beta1.Rule_From{{
+							Source: &v1beta1.Source{
+								Principals: []string{gateway, "cluster.local/ns/starlord/sa/gamora"},
+							},
+						}},
+						When: []*v1beta1.Condition{{
+							Key:    "request.headers[kubeflow-userid]",
+							Values: []string{"gamora@guardians.net"},
+						}},
+					}, {
+						From: []*v1beta1.Rule_From{{
+							Source: &v1beta1.Source{
+								Principals: []string{gateway, "cluster.local/ns/starlord/sa/starlord"},
+							},
+						}},
+						When: []*v1beta1.Condition{{
+							Key:    "request.headers[kubeflow-userid]",
+							Values: []string{"starlord@guardians.net"},
+						}},
+					}},
+				},
+			}},
+		},
+		"AddsTheServiceAccountOfEachContributorOfAUser": {
+			initObjs: append([]client.Object{
+				&v1alpha1.Contributor{
+					ObjectMeta: metav1.ObjectMeta{Name: "starlord-pipelines", Namespace: "starlord"},
+					Spec:       v1alpha1.ContributorSpec{Role: "Contributor", Name: "starlord@guardians.net"},
+				},
+			}, contributors[:1]...),
+			want: []*istiosecurity.AuthorizationPolicy{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            PrivatePolicyName,
+					Namespace:       "starlord",
+					Labels:          map[string]string{"app.kubernetes.io/part-of": "kubeflow-profile"},
+					OwnerReferences: ownedBy("Profile", "kubeflow.org/v1alpha1", "1234"),
+				},
+				Spec: v1beta1.AuthorizationPolicy{
+					Action: v1beta1.AuthorizationPolicy_ALLOW,
+					Rules: []*v1beta1.Rule{{
+						From: []*v1beta1.Rule_From{{
+							Source: &v1beta1.Source{
+								Principals: []string{
+									gateway,
+									"cluster.local/ns/starlord/sa/starlord",
+									"cluster.local/ns/starlord/sa/starlord-pipelines",
+								},
+							},
+						}},
+						When: []*v1beta1.Condition{{
+							Key:    "request.headers[kubeflow-userid]",
+							Values: []string{"starlord@guardians.net"},
+						}},
+					}},
+				},
+			}},
+		},
+		"UsesTheConfiguredUserIDAndPrincipals": {
+			opts: []PolicyReconcilerOption{
+				WithPolicyUserIDHeader("x-goog-authenticated-user-email"),
+				WithPolicyUserIDPrefix("accounts.google.com:"),
+				WithPolicyIstioConfig(controller.IstioConfig{
+					TrustDomain:    "guardians.net",
+					IngressGateway: controller.ServiceAccount{Namespace: "gateways", Name: "kubeflow-gateway"},
+				}),
+			},
+			initObjs: contributors[:1],
+			want: []*istiosecurity.AuthorizationPolicy{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            PrivatePolicyName,
+					Namespace:       "starlord",
+					Labels:          map[string]string{"app.kubernetes.io/part-of": "kubeflow-profile"},
+					OwnerReferences: ownedBy("Profile", "kubeflow.org/v1alpha1", "1234"),
+				},
+				Spec: v1beta1.AuthorizationPolicy{
+					Action: v1beta1.AuthorizationPolicy_ALLOW,
+					Rules: []*v1beta1.Rule{{
+						From: []*v1beta1.Rule_From{{
+							Source: &v1beta1.Source{
+								Principals: []string{
+									"guardians.net/ns/gateways/sa/kubeflow-gateway",
+									"guardians.net/ns/starlord/sa/starlord",
+								},
+							},
+						}},
+						When: []*v1beta1.Condition{{
+							Key:    "request.headers[x-goog-authenticated-user-email]",
+							Values: []string{"accounts.google.com:starlord@guardians.net"},
+						}},
+					}},
+				},
+			}},
+		},
+		"OwnsThePoliciesByTheNamespaceWithoutAProfile": {
+			namespace: &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "starlord", UID: "5678"}},
+			initObjs:  contributors[:1],
+			want: []*istiosecurity.AuthorizationPolicy{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            PublicPolicyName,
+					Namespace:       "starlord",
+					Labels:          map[string]string{"app.kubernetes.io/part-of": "kubeflow-profile"},
+					OwnerReferences: ownedBy("Namespace", "v1", "5678"),
+				},
+				Spec: v1beta1.AuthorizationPolicy{
+					Action: v1beta1.AuthorizationPolicy_ALLOW,
+					Rules: []*v1beta1.Rule{{
+						From: []*v1beta1.Rule_From{{
+							Source: &v1beta1.Source{Principals: []string{gateway}},
+						}},
+						When: []*v1beta1.Condition{{
+							Key:    "request.headers[kubeflow-userid]",
+							Values: []string{"starlord@guardians.net"},
+						}},
+					}},
+					Selector: &v1beta12.WorkloadSelector{
+						MatchLabels: map[string]string{"kubeflow.org/visibility": "public"},
+					},
+				},
+			}},
+		},
+		"DeletesThePerContributorPolicies": {
+			initObjs: append([]client.Object{
+				legacy("starlord", "starlord-public"),
+				legacy("starlord", "starlord-private"),
+				legacy("gamora", "gamora-private"),
+				legacy("gamora", "notebooks"),
+			}, contributors...),
+			want:    []*istiosecurity.AuthorizationPolicy{legacy("gamora", "notebooks")},
+			deleted: []string{"starlord-public", "starlord-private", "gamora-private"},
+		},
+		"DeletesThePoliciesWithoutContributors": {
+			initObjs: []client.Object{
+				labeled(PublicPolicyName),
+				labeled(PrivatePolicyName),
+			},
+			deleted: []string{PublicPolicyName, PrivatePolicyName},
+		},
+	}
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+	qt.Assert(t, istiosecurity.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	for name, subtest := range cases {
+		t.Run(name, func(t *testing.T) {
+
+			namespace := owned.DeepCopy()
+			if subtest.namespace != nil {
+				namespace = subtest.namespace
+			}
+			k8s := fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(namespace, profile.DeepCopy()).
+				WithObjects(subtest.initObjs...).
+				Build()
+
+			r := NewPolicyReconciler(manager.FromClient(k8s), subtest.opts...)
+			res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(namespace)})
+			qt.Assert(t, err, qt.IsNil)
+			qt.Assert(t, res, qt.Equals, ctrl.Result{})
+
+			for _, want := range subtest.want {
+				got := &istiosecurity.AuthorizationPolicy{}
+				qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(want), got), qt.IsNil)
+
+				out, _ := json.Marshal(got)
+				have := make(map[string]any)
+				qt.Assert(t, json.Unmarshal(out, &have), qt.IsNil)
+				expected := make(map[string]any)
+				out, _ = json.Marshal(want)
+				qt.Assert(t, json.Unmarshal(out, &expected), qt.IsNil)
+				qt.Assert(t, have, qt.CmpEquals(
+					cmpopts.IgnoreMapEntries(func(T, R any) bool {
+						return sets.NewString("resourceVersion", "apiVersion", "kind").Has(T.(string))
+					}),
+				), expected)
+			}
+
+			for _, name := range subtest.deleted {
+				err := k8s.Get(ctx, client.ObjectKey{Namespace: "starlord", Name: name}, &istiosecurity.AuthorizationPolicy{})
+				qt.Assert(t, apierrors.IsNotFound(err), qt.IsTrue)
+			}
+		})
+	}
+}
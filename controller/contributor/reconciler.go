@@ -5,35 +5,54 @@ import (
 	"crypto/md5"
 	"fmt"
 
-	"github.com/crossplane/crossplane-runtime/pkg/controller"
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/pkg/errors"
 	"istio.io/api/security/v1beta1"
-	v1beta12 "istio.io/api/type/v1beta1"
 	istiosecurity "istio.io/client-go/pkg/apis/security/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/johnhoman/kubeflow-profile-manager/apis/v1alpha1"
+	"github.com/johnhoman/kubeflow-profile-manager/controller"
 	"github.com/johnhoman/kubeflow-profile-manager/controller/features"
 	"github.com/johnhoman/kubeflow-profile-manager/controller/manager"
 )
 
 const (
-	errReconcileServiceAccount      = "failed to reconcile service account"
-	errReconcileRoleBinding         = "failed to reconcile role binding"
-	errReconcileAuthorizationPolicy = "failed to reconcile authorization policy"
+	errReconcileServiceAccount = "failed to reconcile service account"
+	errReconcileRoleBinding    = "failed to reconcile role binding"
+	errUpdateStatus            = "failed to update contributor status"
 
-	errFmtSetControllerRef = "failed to set controller reference on %s"
+	errFmtSetControllerRef        = "failed to set controller reference on %s"
+	errFmtReadAuthorizationPolicy = "failed to read authorization policy %s"
+	errFmtPolicyPending           = "authorization policy %s does not include the contributor yet"
+
+	// Skipped result is returned from a reconciler that is disabled. The condition
+	// for a skipped step is removed from the contributor status
+	Skipped = controllerutil.OperationResult("Skipped")
+
+	reasonCreated        event.Reason = "Created"
+	reasonUpdated        event.Reason = "Updated"
+	reasonReconcileError event.Reason = "ReconcileError"
 )
+
 // +kubebuilder:rbac:groups=kubeflow.org,resources=contributors,verbs=create;update;delete;patch;get;list;watch
+// +kubebuilder:rbac:groups=kubeflow.org,resources=contributors/status,verbs=patch
 // +kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=create;update;delete;patch;get;list;watch
 // +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs=create;update;delete;get;list;patch;watch
 // +kubebuilder:rbac:groups=security.istio.io,resources=authorizationpolicies,verbs=create;update;delete;get;list;patch;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 func Setup(mgr ctrl.Manager, o controller.Options, opts ...ReconcilerOption) error {
 
@@ -44,6 +63,12 @@ func Setup(mgr ctrl.Manager, o controller.Options, opts ...ReconcilerOption) err
 		WithDefaultRoleBindingReconcilerFunc(),
 		WithLogger(o.Logger.WithValues("controller", name)),
 	)
+	if o.Recorder != nil {
+		opts = append(opts, WithRecorder(o.Recorder))
+	}
+	if o.Istio != nil {
+		opts = append(opts, WithIstioConfig(*o.Istio))
+	}
 	builder := ctrl.NewControllerManagedBy(mgr).
 		Named(name).
 		WithOptions(o.ForControllerRuntime()).
@@ -51,9 +76,13 @@ func Setup(mgr ctrl.Manager, o controller.Options, opts ...ReconcilerOption) err
 		Owns(&corev1.ServiceAccount{}).
 		Owns(&rbacv1.RoleBinding{})
 
+	// the namespace AuthorizationPolicies are rendered by the PolicyReconciler
 	if o.Features.Enabled(features.Istio) {
-		builder.Owns(&istiosecurity.AuthorizationPolicy{})
-		opts = append(opts, WithIstioEnabled())
+		opts = append(opts, WithDefaultAuthorizationPolicyReconcilerFunc())
+		builder.Watches(
+			&source.Kind{Type: &istiosecurity.AuthorizationPolicy{}},
+			handler.EnqueueRequestsFromMapFunc(contributorsInNamespace(mgr.GetClient())),
+		)
 	}
 
 	if o.Features.Enabled(features.Pipelines) {
@@ -77,9 +106,11 @@ func WithUserIDHeader(header string) ReconcilerOption {
 	}
 }
 
-func WithIstioEnabled() ReconcilerOption {
+// WithIstioConfig sets the principals expected in the namespace
+// AuthorizationPolicies
+func WithIstioConfig(config controller.IstioConfig) ReconcilerOption {
 	return func(r *Reconciler) {
-		r.istio = r.ReconcileIstioAuthorizationPolicy
+		r.istioConfig = config
 	}
 }
 
@@ -105,29 +136,49 @@ func WithDefaultRoleBindingReconcilerFunc() ReconcilerOption {
 	}
 }
 
+// WithDefaultAuthorizationPolicyReconcilerFunc reports whether the namespace
+// AuthorizationPolicies rendered by the PolicyReconciler include the contributor
+func WithDefaultAuthorizationPolicyReconcilerFunc() ReconcilerOption {
+	return func(r *Reconciler) {
+		r.publicPolicy = r.ReconcilePublicAuthorizationPolicy
+		r.privatePolicy = r.ReconcilePrivateAuthorizationPolicy
+	}
+}
+
 func WithLogger(logger logging.Logger) ReconcilerOption {
 	return func(r *Reconciler) {
 		r.logger = logger
 	}
 }
 
+func WithRecorder(recorder event.Recorder) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.recorder = recorder
+	}
+}
+
 type ReconcileFunc func(ctx context.Context, contributor *v1alpha1.Contributor) (controllerutil.OperationResult, error)
 
+// NopReconcileFunc is the ReconcileFunc for disabled features. It always
+// returns Skipped
 func NopReconcileFunc(context.Context, *v1alpha1.Contributor) (controllerutil.OperationResult, error) {
-	return controllerutil.OperationResultNone, nil
+	return Skipped, nil
 }
 
 func NewReconciler(mgr manager.Manager, opts ...ReconcilerOption) *Reconciler {
 	r := &Reconciler{
 		client:       mgr.GetClient(),
 		logger:       logging.NewNopLogger(),
+		recorder:     event.NewNopRecorder(),
 		userIDHeader: "kubeflow-userid",
 
 		contributorRole: corev1.LocalObjectReference{Name: "kubeflow-edit"},
+		istioConfig:     controller.DefaultIstioConfig(),
 		// reconcile features
-		istio:          NopReconcileFunc,
 		roleBinding:    NopReconcileFunc,
 		serviceAccount: NopReconcileFunc,
+		publicPolicy:   NopReconcileFunc,
+		privatePolicy:  NopReconcileFunc,
 	}
 	for _, f := range opts {
 		f(r)
@@ -136,19 +187,23 @@ func NewReconciler(mgr manager.Manager, opts ...ReconcilerOption) *Reconciler {
 }
 
 type Reconciler struct {
-	client client.Client
-	logger logging.Logger
+	client   client.Client
+	logger   logging.Logger
+	recorder event.Recorder
 
 	contributorRole corev1.LocalObjectReference
 
+	istioConfig controller.IstioConfig
+
 	// user id
 	userIDPrefix string
 	userIDHeader string
 
 	// Features
-	istio          ReconcileFunc
 	roleBinding    ReconcileFunc
 	serviceAccount ReconcileFunc
+	publicPolicy   ReconcileFunc
+	privatePolicy  ReconcileFunc
 }
 
 func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -158,23 +213,121 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, errors.Wrap(client.IgnoreNotFound(err), "failed to read profile")
 	}
 
-	funcs := []ReconcileFunc{
-		r.serviceAccount,
-		r.roleBinding,
-		r.istio,
+	patch := client.MergeFrom(contributor.DeepCopy())
+
+	steps := []step{
+		{condition: v1alpha1.TypeServiceAccountReady, resource: "service account", reconcile: r.serviceAccount},
+		{condition: v1alpha1.TypeRoleBindingReady, resource: "role binding", reconcile: r.roleBinding},
+		{condition: v1alpha1.TypePublicAuthorizationPolicyReady, resource: "public authorization policy", reconcile: r.publicPolicy},
+		{condition: v1alpha1.TypePrivateAuthorizationPolicyReady, resource: "private authorization policy", reconcile: r.privatePolicy},
 	}
 
-	for _, f := range funcs {
-		res, err := f(ctx, contributor)
+	var reconcileErr error
+	for _, s := range steps {
+		res, err := s.reconcile(ctx, contributor)
+		if e, ok := s.eventFor(res, err); ok {
+			r.recorder.Event(contributor, e)
+		}
 		if err != nil {
-			return ctrl.Result{}, err
+			reconcileErr = err
+			contributor.Status.SetConditions(s.conditionFor(res, err))
+			break
 		}
-		switch res {
-		case controllerutil.OperationResultCreated:
-		case controllerutil.OperationResultUpdated:
+		if res == Skipped {
+			contributor.Status.RemoveConditions(s.condition)
+			continue
 		}
+		contributor.Status.SetConditions(s.conditionFor(res, nil))
+	}
+
+	contributor.Status.SetConditions(synced(reconcileErr), ready(contributor, steps))
+	contributor.Status.ObservedGeneration = contributor.Generation
+	if err := r.client.Status().Patch(ctx, contributor, patch); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, errUpdateStatus)
 	}
-	return ctrl.Result{}, nil
+	return ctrl.Result{}, reconcileErr
+}
+
+// step is a single ReconcileFunc along with the condition it reports
+// on the contributor status
+type step struct {
+	condition xpv1.ConditionType
+	// resource is a human readable name of the resource managed by the step
+	resource  string
+	reconcile ReconcileFunc
+}
+
+// conditionFor returns the condition for a step given the result of its
+// ReconcileFunc
+func (s step) conditionFor(res controllerutil.OperationResult, err error) xpv1.Condition {
+	c := xpv1.Condition{
+		Type:               s.condition,
+		Status:             corev1.ConditionTrue,
+		Reason:             v1alpha1.ReasonAvailable,
+		LastTransitionTime: metav1.Now(),
+	}
+	switch {
+	case err != nil:
+		c.Status = corev1.ConditionFalse
+		c.Reason = v1alpha1.ReasonReconcileError
+		c.Message = err.Error()
+	case res == controllerutil.OperationResultCreated:
+		c.Reason = v1alpha1.ReasonCreated
+	case res == controllerutil.OperationResultUpdated,
+		res == controllerutil.OperationResultUpdatedStatus,
+		res == controllerutil.OperationResultUpdatedStatusOnly:
+		c.Reason = v1alpha1.ReasonUpdated
+	}
+	return c
+}
+
+// eventFor returns the event to record for a step given the result of its
+// ReconcileFunc. No event is recorded when nothing changed
+func (s step) eventFor(res controllerutil.OperationResult, err error) (event.Event, bool) {
+	switch {
+	case err != nil:
+		return event.Warning(reasonReconcileError, err), true
+	case res == controllerutil.OperationResultCreated:
+		return event.Normal(reasonCreated, fmt.Sprintf("created %s", s.resource)), true
+	case res == controllerutil.OperationResultUpdated:
+		return event.Normal(reasonUpdated, fmt.Sprintf("updated %s", s.resource)), true
+	}
+	return event.Event{}, false
+}
+
+// synced returns the Synced condition given the error returned from
+// reconciliation, if any
+func synced(err error) xpv1.Condition {
+	if err != nil {
+		return xpv1.ReconcileError(err)
+	}
+	return xpv1.ReconcileSuccess()
+}
+
+// ready returns the aggregate Ready condition. A contributor is ready when
+// the condition for every enabled step is True
+func ready(contributor *v1alpha1.Contributor, steps []step) xpv1.Condition {
+	for _, s := range steps {
+		c := contributor.Status.GetCondition(s.condition)
+		if c.Status == corev1.ConditionTrue || !hasCondition(contributor, s.condition) {
+			continue
+		}
+		msg := fmt.Sprintf("%s is %s", c.Type, c.Status)
+		if c.Message != "" {
+			msg = fmt.Sprintf("%s: %s", msg, c.Message)
+		}
+		return xpv1.Unavailable().WithMessage(msg)
+	}
+	return xpv1.Available()
+}
+
+func hasCondition(contributor *v1alpha1.Contributor, ct xpv1.ConditionType) bool {
+	for _, c := range contributor.Status.Conditions {
+		if c.Type == ct {
+			return true
+		}
+	}
+	return false
 }
 
 func (r *Reconciler) ReconcileServiceAccount(ctx context.Context, contributor *v1alpha1.Contributor) (controllerutil.OperationResult, error) {
@@ -223,85 +376,85 @@ func (r *Reconciler) ReconcileRoleBinding(ctx context.Context, contributor *v1al
 		}}
 		return nil
 	})
-	return res, errors.Wrap(err, errReconcileRoleBinding)
+	if err != nil {
+		return res, errors.Wrap(err, errReconcileRoleBinding)
+	}
+	contributor.Status.ClusterRole = binding.RoleRef.Name
+	return res, nil
 }
 
-func (r *Reconciler) ReconcileIstioAuthorizationPolicy(ctx context.Context, contributor *v1alpha1.Contributor) (controllerutil.OperationResult, error) {
-
-	// TODO: AuthorizationPolicy for all public Notebooks e.g.
-	//   selector:
-	//     matchLabels:
-	//       kubeflow.org/visibility: private
-	public := &istiosecurity.AuthorizationPolicy{}
-	public.Name = fmt.Sprintf("%s-public", contributor.Name)
-	public.Namespace = contributor.Namespace
-	res, err := controllerutil.CreateOrPatch(ctx, r.client, public, func() error {
-		if err := controllerutil.SetControllerReference(contributor, public, r.client.Scheme()); err != nil {
-			return errors.Wrapf(err, errFmtSetControllerRef, "AuthorizationPolicy")
+// ReconcilePublicAuthorizationPolicy checks that the namespace public
+// AuthorizationPolicy accepts the contributor through the ingress gateway
+func (r *Reconciler) ReconcilePublicAuthorizationPolicy(ctx context.Context, contributor *v1alpha1.Contributor) (controllerutil.OperationResult, error) {
+	return controllerutil.OperationResultNone, r.checkNamespacePolicy(ctx, contributor, PublicPolicyName,
+		r.istioConfig.IngressGatewayPrincipal(),
+	)
+}
+
+// ReconcilePrivateAuthorizationPolicy checks that the namespace private
+// AuthorizationPolicy accepts the contributor through the ingress gateway and
+// its own ServiceAccount
+func (r *Reconciler) ReconcilePrivateAuthorizationPolicy(ctx context.Context, contributor *v1alpha1.Contributor) (controllerutil.OperationResult, error) {
+	return controllerutil.OperationResultNone, r.checkNamespacePolicy(ctx, contributor, PrivatePolicyName,
+		r.istioConfig.IngressGatewayPrincipal(),
+		r.istioConfig.Principal(controller.ServiceAccount{Namespace: contributor.Namespace, Name: contributor.Name}),
+	)
+}
+
+// checkNamespacePolicy returns an error unless the namespace AuthorizationPolicy
+// has a rule that accepts the contributor user from every principal
+func (r *Reconciler) checkNamespacePolicy(ctx context.Context, contributor *v1alpha1.Contributor, name string, principals ...string) error {
+	policy := &istiosecurity.AuthorizationPolicy{}
+	if err := r.client.Get(ctx, client.ObjectKey{Namespace: contributor.Namespace, Name: name}, policy); err != nil {
+		if apierrors.IsNotFound(err) {
+			return errors.Errorf(errFmtPolicyPending, name)
 		}
-		public.Spec = v1beta1.AuthorizationPolicy{
-			Action: v1beta1.AuthorizationPolicy_ALLOW,
-			Rules: []*v1beta1.Rule{{
-				When: []*v1beta1.Condition{{
-					Key:    fmt.Sprintf("request.headers[%v]", r.userIDHeader),
-					Values: []string{r.userIDPrefix + contributor.Spec.Name},
-				}},
-				From: []*v1beta1.Rule_From{{
-					Source: &v1beta1.Source{
-						Principals: []string{principalIstioIngressGateway},
-					},
-				}},
-			}},
-			Selector: &v1beta12.WorkloadSelector{
-				MatchLabels: map[string]string{
-					"kubeflow.org/visibility": "public",
-				},
-			},
+		return errors.Wrapf(err, errFmtReadAuthorizationPolicy, name)
+	}
+	key := fmt.Sprintf("request.headers[%v]", r.userIDHeader)
+	user := r.userIDPrefix + contributor.Spec.Name
+	for _, rule := range policy.Spec.Rules {
+		if allows(rule, key, user, principals) {
+			return nil
 		}
-		return nil
-	})
-	if err != nil {
-		r.logger.Debug("failed to reconcile contributor public istio AuthorizationPolicy",
-			"error", err.Error())
-		return res, err
 	}
+	return errors.Errorf(errFmtPolicyPending, name)
+}
 
-	policy := &istiosecurity.AuthorizationPolicy{}
-	policy.Name = fmt.Sprintf("%s-private", contributor.Name)
-	policy.Namespace = contributor.Namespace
+// allows returns true when the rule accepts the user from every principal
+func allows(rule *v1beta1.Rule, key, user string, principals []string) bool {
+	users := sets.NewString()
+	for _, c := range rule.When {
+		if c.Key == key {
+			users.Insert(c.Values...)
+		}
+	}
+	sources := sets.NewString()
+	for _, from := range rule.From {
+		if from.Source != nil {
+			sources.Insert(from.Source.Principals...)
+		}
+	}
+	return users.Has(user) && sources.HasAll(principals...)
+}
 
-	res, err = controllerutil.CreateOrPatch(ctx, r.client, policy, func() error {
-		if err := controllerutil.SetControllerReference(contributor, policy, r.client.Scheme()); err != nil {
-			return errors.Wrapf(err, errFmtSetControllerRef, "AuthorizationPolicy")
+// contributorsInNamespace maps a namespace AuthorizationPolicy to the
+// contributors in its namespace
+func contributorsInNamespace(cli client.Reader) handler.MapFunc {
+	return func(o client.Object) []ctrl.Request {
+		if o.GetName() != PublicPolicyName && o.GetName() != PrivatePolicyName {
+			return nil
 		}
-		policy.Spec = v1beta1.AuthorizationPolicy{
-			Action: v1beta1.AuthorizationPolicy_ALLOW,
-			Rules: []*v1beta1.Rule{{
-				When: []*v1beta1.Condition{{
-					// Namespace Owner can access all workloads in the
-					// namespace
-					Key:    fmt.Sprintf("request.headers[%v]", r.userIDHeader),
-					Values: []string{r.userIDPrefix + contributor.Spec.Name},
-				}},
-				From: []*v1beta1.Rule_From{{
-					Source: &v1beta1.Source{
-						Principals: []string{
-							principalIstioIngressGateway,
-							fmt.Sprintf("cluster.local/ns/%s/sa/%s", contributor.Namespace, contributor.Name),
-						},
-					},
-				}},
-			}},
-			Selector: &v1beta12.WorkloadSelector{
-				MatchLabels: map[string]string{
-					"owner.kubeflow.org/id": md5Sum(contributor.Spec.Name),
-				},
-			},
+		contributorList := &v1alpha1.ContributorList{}
+		if err := cli.List(context.Background(), contributorList, client.InNamespace(o.GetNamespace())); err != nil {
+			return nil
 		}
-		return nil
-	})
-	r.logger.Debug("finished reconciling authorization policy")
-	return res, errors.Wrap(err, errReconcileAuthorizationPolicy)
+		requests := make([]ctrl.Request, 0, len(contributorList.Items))
+		for _, item := range contributorList.Items {
+			requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&item)})
+		}
+		return requests
+	}
 }
 
 var _ reconcile.Reconciler = &Reconciler{}
@@ -325,7 +478,3 @@ func addAnnotation(o client.Object, key, value string) {
 	annotations[key] = value
 	o.SetAnnotations(annotations)
 }
-
-const (
-	principalIstioIngressGateway = "cluster.local/ns/istio-system/sa/istio-ingressgateway-service-account"
-)
@@ -1 +1,23 @@
 package controller
+
+import (
+	"github.com/crossplane/crossplane-runtime/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+)
+
+// Options are the options shared by the profile manager controllers
+type Options struct {
+	controller.Options
+
+	// Recorder records Kubernetes events for reconciled objects. Events are
+	// discarded when Recorder is nil
+	Recorder event.Recorder
+
+	// Istio configures the Istio AuthorizationPolicies created by the
+	// controllers. DefaultIstioConfig is used when Istio is nil
+	Istio *IstioConfig
+
+	// AWSOIDCProvider is the EKS cluster OIDC provider the AWS IAM role trust
+	// policies federate with
+	AWSOIDCProvider string
+}
@@ -6,4 +6,8 @@ const (
 	Istio             feature.Flag = "Istio"
 	NamespaceAdoption feature.Flag = "NamespaceAdoption"
 	Pipelines         feature.Flag = "Pipelines"
+	NetworkPolicy     feature.Flag = "NetworkPolicy"
+	KServe            feature.Flag = "KServe"
+	StrictMTLS        feature.Flag = "StrictMTLS"
+	SidecarEgress     feature.Flag = "SidecarEgress"
 )
@@ -0,0 +1,75 @@
+package controller
+
+import "fmt"
+
+// IstioConfig configures the Istio AuthorizationPolicies the controllers
+// create for profiles and contributors
+type IstioConfig struct {
+	// TrustDomain of the mesh. Principals are formatted as
+	// <trust domain>/ns/<namespace>/sa/<service account>
+	TrustDomain string
+
+	// NotebookController is the service account of the notebook controller
+	NotebookController ServiceAccount
+
+	// IngressGateway is the service account of the Istio ingress gateway that
+	// forwards authenticated user requests
+	IngressGateway ServiceAccount
+
+	// ClusterLocalGateway is the service account of the Istio gateway that
+	// routes in-cluster requests to Knative services
+	ClusterLocalGateway ServiceAccount
+
+	// KnativeServingNamespace is the namespace of the Knative activator and
+	// autoscaler
+	KnativeServingNamespace string
+
+	// ProbePaths are the workload paths that are accessible without
+	// authentication, such as the paths probed by Knative system pods
+	ProbePaths []string
+}
+
+// ServiceAccount identifies a service account in the mesh
+type ServiceAccount struct {
+	Namespace string
+	Name      string
+}
+
+// DefaultIstioConfig returns the configuration of a default Kubeflow
+// installation
+func DefaultIstioConfig() IstioConfig {
+	return IstioConfig{
+		TrustDomain:             "cluster.local",
+		NotebookController:      ServiceAccount{Namespace: "kubeflow", Name: "notebook-controller-service-account"},
+		IngressGateway:          ServiceAccount{Namespace: "istio-system", Name: "istio-ingressgateway-service-account"},
+		ClusterLocalGateway:     ServiceAccount{Namespace: "istio-system", Name: "cluster-local-gateway-service-account"},
+		KnativeServingNamespace: "knative-serving",
+		// Workloads paths should be accessible for KNative's `activator` and
+		// `controller` probes
+		// See: https://knative.dev/docs/serving/istio-authorization/#allowing-access-from-system-pods-by-paths
+		ProbePaths: []string{"/healthz", "/metrics", "/wait-for-drain"},
+	}
+}
+
+// Principal returns the mesh principal of the service account
+func (c IstioConfig) Principal(sa ServiceAccount) string {
+	return fmt.Sprintf("%s/ns/%s/sa/%s", c.TrustDomain, sa.Namespace, sa.Name)
+}
+
+// NotebookControllerPrincipal returns the mesh principal of the notebook
+// controller
+func (c IstioConfig) NotebookControllerPrincipal() string {
+	return c.Principal(c.NotebookController)
+}
+
+// ClusterLocalGatewayPrincipal returns the mesh principal of the Istio
+// cluster-local gateway
+func (c IstioConfig) ClusterLocalGatewayPrincipal() string {
+	return c.Principal(c.ClusterLocalGateway)
+}
+
+// IngressGatewayPrincipal returns the mesh principal of the Istio ingress
+// gateway
+func (c IstioConfig) IngressGatewayPrincipal() string {
+	return c.Principal(c.IngressGateway)
+}
@@ -7,5 +7,6 @@ import (
 
 type Manager interface {
 	GetClient() client.Client
+	GetAPIReader() client.Reader
 	GetScheme() *runtime.Scheme
 }
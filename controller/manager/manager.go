@@ -13,7 +13,8 @@ type manager struct {
 	cli client.Client
 }
 
-func (m *manager) GetClient() client.Client   { return m.cli }
-func (m *manager) GetScheme() *runtime.Scheme { return m.cli.Scheme() }
+func (m *manager) GetClient() client.Client    { return m.cli }
+func (m *manager) GetAPIReader() client.Reader { return m.cli }
+func (m *manager) GetScheme() *runtime.Scheme  { return m.cli.Scheme() }
 
 var _ Manager = &manager{}
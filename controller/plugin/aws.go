@@ -0,0 +1,224 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/johnhoman/kubeflow-profile-manager/apis/v1alpha1"
+	"github.com/johnhoman/kubeflow-profile-manager/controller/manager"
+)
+
+const (
+	// KindAWSIAMForServiceAccount is the upstream plugin kind for AWS IAM
+	// roles for service accounts (IRSA)
+	KindAWSIAMForServiceAccount = "AwsIamForServiceAccount"
+
+	// AnnotationAWSRoleARN binds a Kubernetes ServiceAccount to an AWS IAM role
+	AnnotationAWSRoleARN = "eks.amazonaws.com/role-arn"
+
+	// TrustPolicyConfigMapName is the name of the ConfigMap in the profile
+	// namespace that holds the generated IAM role trust policy
+	TrustPolicyConfigMapName = "aws-iam-trust-policy"
+	// TrustPolicyKey is the ConfigMap key of the trust policy document
+	TrustPolicyKey = "trust-policy.json"
+	// RoleARNKey is the ConfigMap key of the IAM role the trust policy is for
+	RoleARNKey = "role-arn"
+
+	errAWSIAMRoleRequired      = "awsIamRole is required"
+	errAWSOIDCProviderRequired = "an OIDC provider is required to generate the trust policy"
+	errFmtInvalidRoleARN       = "invalid IAM role ARN %q"
+	errReconcileTrustPolicy    = "failed to reconcile trust policy ConfigMap"
+	errDeleteTrustPolicy       = "failed to delete trust policy ConfigMap"
+	errMarshalTrustPolicy      = "failed to marshal trust policy"
+	errFmtSetControllerRef     = "failed to set controller reference on %s"
+)
+
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+
+// AWSIAMForServiceAccountSpec is the spec of an AwsIamForServiceAccount plugin
+type AWSIAMForServiceAccountSpec struct {
+	// AWSIAMRole is the ARN of the IAM role the profile service accounts assume
+	AWSIAMRole string `json:"awsIamRole"`
+
+	// AnnotateOnly only annotates the service accounts and does not
+	// generate a trust policy
+	AnnotateOnly bool `json:"annotateOnly,omitempty"`
+
+	// OIDCProvider overrides the cluster OIDC provider the trust policy
+	// federates with, e.g. oidc.eks.us-west-2.amazonaws.com/id/EXAMPLE
+	OIDCProvider string `json:"oidcProvider,omitempty"`
+}
+
+type AWSIAMForServiceAccountOption func(p *AWSIAMForServiceAccount)
+
+// WithOIDCProvider sets the cluster OIDC provider the generated trust
+// policy federates with. The provider is the issuer URL without the scheme
+func WithOIDCProvider(provider string) AWSIAMForServiceAccountOption {
+	return func(p *AWSIAMForServiceAccount) {
+		p.oidcProvider = strings.TrimPrefix(provider, "https://")
+	}
+}
+
+// NewAWSIAMForServiceAccount returns the AWS IRSA plugin. The plugin annotates
+// the profile ServiceAccounts with the IAM role and generates the role trust
+// policy into a ConfigMap in the profile namespace. Applying the trust policy
+// to the IAM role is left to the infrastructure pipeline.
+func NewAWSIAMForServiceAccount(mgr manager.Manager, opts ...AWSIAMForServiceAccountOption) *AWSIAMForServiceAccount {
+	p := &AWSIAMForServiceAccount{client: mgr.GetClient()}
+	for _, f := range opts {
+		f(p)
+	}
+	return p
+}
+
+type AWSIAMForServiceAccount struct {
+	client       client.Client
+	oidcProvider string
+}
+
+func (p *AWSIAMForServiceAccount) Apply(ctx context.Context, profile *v1alpha1.Profile, raw *runtime.RawExtension) error {
+	spec := &AWSIAMForServiceAccountSpec{}
+	if err := decode(raw, spec); err != nil {
+		return err
+	}
+	if spec.AWSIAMRole == "" {
+		return errors.New(errAWSIAMRoleRequired)
+	}
+	if _, _, err := parseRoleARN(spec.AWSIAMRole); err != nil {
+		return err
+	}
+	err := patchServiceAccounts(ctx, p.client, profile, func(sa *corev1.ServiceAccount) {
+		setAnnotation(sa, AnnotationAWSRoleARN, spec.AWSIAMRole)
+	})
+	if err != nil {
+		return err
+	}
+	if spec.AnnotateOnly {
+		return p.deleteTrustPolicy(ctx, profile)
+	}
+
+	provider := p.oidcProvider
+	if spec.OIDCProvider != "" {
+		provider = strings.TrimPrefix(spec.OIDCProvider, "https://")
+	}
+	if provider == "" {
+		return errors.New(errAWSOIDCProviderRequired)
+	}
+	items, err := serviceAccounts(ctx, p.client, profile)
+	if err != nil {
+		return err
+	}
+	keys := make([]client.ObjectKey, 0, len(items))
+	for k := range items {
+		keys = append(keys, client.ObjectKeyFromObject(&items[k]))
+	}
+	document, err := TrustPolicy(spec.AWSIAMRole, provider, keys)
+	if err != nil {
+		return err
+	}
+
+	cm := &corev1.ConfigMap{}
+	cm.Name = TrustPolicyConfigMapName
+	cm.Namespace = profile.TargetNamespace()
+	_, err = controllerutil.CreateOrUpdate(ctx, p.client, cm, func() error {
+		if err := controllerutil.SetControllerReference(profile, cm, p.client.Scheme()); err != nil {
+			return errors.Wrapf(err, errFmtSetControllerRef, "ConfigMap")
+		}
+		setLabel(cm, "app.kubernetes.io/part-of", "kubeflow-profile")
+		cm.Data = map[string]string{
+			RoleARNKey:     spec.AWSIAMRole,
+			TrustPolicyKey: document,
+		}
+		return nil
+	})
+	return errors.Wrap(err, errReconcileTrustPolicy)
+}
+
+func (p *AWSIAMForServiceAccount) Revoke(ctx context.Context, profile *v1alpha1.Profile, _ *runtime.RawExtension) error {
+	err := patchServiceAccounts(ctx, p.client, profile, func(sa *corev1.ServiceAccount) {
+		removeAnnotation(sa, AnnotationAWSRoleARN)
+	})
+	if err != nil {
+		return err
+	}
+	return p.deleteTrustPolicy(ctx, profile)
+}
+
+func (p *AWSIAMForServiceAccount) deleteTrustPolicy(ctx context.Context, profile *v1alpha1.Profile) error {
+	cm := &corev1.ConfigMap{}
+	cm.Name = TrustPolicyConfigMapName
+	cm.Namespace = profile.TargetNamespace()
+	return errors.Wrap(client.IgnoreNotFound(p.client.Delete(ctx, cm)), errDeleteTrustPolicy)
+}
+
+type trustPolicy struct {
+	Version   string                 `json:"Version"`
+	Statement []trustPolicyStatement `json:"Statement"`
+}
+
+type trustPolicyStatement struct {
+	Effect    string                         `json:"Effect"`
+	Principal map[string]string              `json:"Principal"`
+	Action    string                         `json:"Action"`
+	Condition map[string]map[string][]string `json:"Condition"`
+}
+
+// TrustPolicy returns the IAM role trust policy document that allows the
+// ServiceAccounts to assume the role through the cluster OIDC provider. The
+// document is the same for the same set of ServiceAccounts regardless of their
+// order.
+func TrustPolicy(roleARN, oidcProvider string, serviceAccounts []client.ObjectKey) (string, error) {
+	partition, account, err := parseRoleARN(roleARN)
+	if err != nil {
+		return "", err
+	}
+
+	subjects := make([]string, 0, len(serviceAccounts))
+	for _, key := range serviceAccounts {
+		subjects = append(subjects, fmt.Sprintf("system:serviceaccount:%s:%s", key.Namespace, key.Name))
+	}
+	sort.Strings(subjects)
+
+	policy := trustPolicy{
+		Version: "2012-10-17",
+		Statement: []trustPolicyStatement{{
+			Effect: "Allow",
+			Principal: map[string]string{
+				"Federated": fmt.Sprintf("arn:%s:iam::%s:oidc-provider/%s", partition, account, oidcProvider),
+			},
+			Action: "sts:AssumeRoleWithWebIdentity",
+			Condition: map[string]map[string][]string{
+				"StringEquals": {
+					oidcProvider + ":aud": {"sts.amazonaws.com"},
+					oidcProvider + ":sub": subjects,
+				},
+			},
+		}},
+	}
+	raw, err := json.MarshalIndent(policy, "", "  ")
+	if err != nil {
+		return "", errors.Wrap(err, errMarshalTrustPolicy)
+	}
+	return string(raw), nil
+}
+
+// parseRoleARN returns the partition and account of an IAM role ARN such as
+// arn:aws:iam::123456789012:role/name
+func parseRoleARN(roleARN string) (string, string, error) {
+	parts := strings.SplitN(roleARN, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "iam" || parts[4] == "" || !strings.HasPrefix(parts[5], "role/") {
+		return "", "", errors.Errorf(errFmtInvalidRoleARN, roleARN)
+	}
+	return parts[1], parts[4], nil
+}
+
+var _ Plugin = &AWSIAMForServiceAccount{}
@@ -0,0 +1,206 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/johnhoman/kubeflow-profile-manager/apis/v1alpha1"
+	"github.com/johnhoman/kubeflow-profile-manager/controller/manager"
+)
+
+const (
+	testRoleARN      = "arn:aws:iam::123456789012:role/starlord"
+	testOIDCProvider = "oidc.eks.us-west-2.amazonaws.com/id/EXAMPLE"
+	testTrustPolicy  = `{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Effect": "Allow",
+      "Principal": {
+        "Federated": "arn:aws:iam::123456789012:oidc-provider/oidc.eks.us-west-2.amazonaws.com/id/EXAMPLE"
+      },
+      "Action": "sts:AssumeRoleWithWebIdentity",
+      "Condition": {
+        "StringEquals": {
+          "oidc.eks.us-west-2.amazonaws.com/id/EXAMPLE:aud": [
+            "sts.amazonaws.com"
+          ],
+          "oidc.eks.us-west-2.amazonaws.com/id/EXAMPLE:sub": [
+            "system:serviceaccount:starlord:gamora",
+            "system:serviceaccount:starlord:starlord"
+          ]
+        }
+      }
+    }
+  ]
+}`
+)
+
+func TestTrustPolicy(t *testing.T) {
+	cases := map[string]struct {
+		roleARN         string
+		serviceAccounts []client.ObjectKey
+		want            string
+		wantErr         string
+	}{
+		"GeneratesATrustPolicy": {
+			roleARN: testRoleARN,
+			serviceAccounts: []client.ObjectKey{
+				{Namespace: "starlord", Name: "starlord"},
+				{Namespace: "starlord", Name: "gamora"},
+			},
+			want: testTrustPolicy,
+		},
+		"IsIndependentOfServiceAccountOrder": {
+			roleARN: testRoleARN,
+			serviceAccounts: []client.ObjectKey{
+				{Namespace: "starlord", Name: "gamora"},
+				{Namespace: "starlord", Name: "starlord"},
+			},
+			want: testTrustPolicy,
+		},
+		"RejectsAnInvalidRoleARN": {
+			roleARN: "arn:aws:s3:::guardians",
+			wantErr: `invalid IAM role ARN "arn:aws:s3:::guardians"`,
+		},
+	}
+
+	for name, subtest := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := TrustPolicy(subtest.roleARN, testOIDCProvider, subtest.serviceAccounts)
+			if subtest.wantErr != "" {
+				qt.Assert(t, err, qt.ErrorMatches, subtest.wantErr)
+				return
+			}
+			qt.Assert(t, err, qt.IsNil)
+			qt.Assert(t, got, qt.Equals, subtest.want)
+		})
+	}
+}
+
+func TestAWSIAMForServiceAccount_Apply(t *testing.T) {
+	cases := map[string]struct {
+		spec          string
+		opts          []AWSIAMForServiceAccountOption
+		initObjs      []client.Object
+		wantErr       string
+		want          map[string]map[string]string
+		wantConfigMap map[string]string
+	}{
+		"AnnotatesServiceAccountsAndGeneratesATrustPolicy": {
+			spec: `{"awsIamRole":"` + testRoleARN + `"}`,
+			opts: []AWSIAMForServiceAccountOption{WithOIDCProvider("https://" + testOIDCProvider)},
+			initObjs: []client.Object{
+				contributorServiceAccount("starlord", nil),
+				contributorServiceAccount("gamora", nil),
+			},
+			want: map[string]map[string]string{
+				"starlord": {AnnotationAWSRoleARN: testRoleARN},
+				"gamora":   {AnnotationAWSRoleARN: testRoleARN},
+			},
+			wantConfigMap: map[string]string{
+				RoleARNKey:     testRoleARN,
+				TrustPolicyKey: testTrustPolicy,
+			},
+		},
+		"UsesTheOIDCProviderFromTheSpec": {
+			spec: `{"awsIamRole":"` + testRoleARN + `","oidcProvider":"` + testOIDCProvider + `"}`,
+			initObjs: []client.Object{
+				contributorServiceAccount("starlord", nil),
+				contributorServiceAccount("gamora", nil),
+			},
+			want: map[string]map[string]string{
+				"starlord": {AnnotationAWSRoleARN: testRoleARN},
+				"gamora":   {AnnotationAWSRoleARN: testRoleARN},
+			},
+			wantConfigMap: map[string]string{
+				RoleARNKey:     testRoleARN,
+				TrustPolicyKey: testTrustPolicy,
+			},
+		},
+		"OnlyAnnotatesWithAnnotateOnly": {
+			spec: `{"awsIamRole":"` + testRoleARN + `","annotateOnly":true}`,
+			initObjs: []client.Object{
+				contributorServiceAccount("starlord", nil),
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: TrustPolicyConfigMapName, Namespace: "starlord"}},
+			},
+			want: map[string]map[string]string{
+				"starlord": {AnnotationAWSRoleARN: testRoleARN},
+			},
+		},
+		"RequiresAnOIDCProvider": {
+			spec:     `{"awsIamRole":"` + testRoleARN + `"}`,
+			initObjs: []client.Object{contributorServiceAccount("starlord", nil)},
+			wantErr:  errAWSOIDCProviderRequired,
+			want: map[string]map[string]string{
+				"starlord": {AnnotationAWSRoleARN: testRoleARN},
+			},
+		},
+		"RejectsAnInvalidRoleARN": {
+			spec:     `{"awsIamRole":"starlord"}`,
+			opts:     []AWSIAMForServiceAccountOption{WithOIDCProvider(testOIDCProvider)},
+			initObjs: []client.Object{contributorServiceAccount("starlord", nil)},
+			wantErr:  `invalid IAM role ARN "starlord"`,
+			want:     map[string]map[string]string{"starlord": nil},
+		},
+	}
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	for name, subtest := range cases {
+		t.Run(name, func(t *testing.T) {
+			k8s := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(subtest.initObjs...).Build()
+
+			p := NewAWSIAMForServiceAccount(manager.FromClient(k8s), subtest.opts...)
+			err := p.Apply(ctx, profile(), &runtime.RawExtension{Raw: []byte(subtest.spec)})
+			if subtest.wantErr != "" {
+				qt.Assert(t, err, qt.ErrorMatches, subtest.wantErr)
+			} else {
+				qt.Assert(t, err, qt.IsNil)
+			}
+
+			for name, want := range subtest.want {
+				sa := &corev1.ServiceAccount{}
+				qt.Assert(t, k8s.Get(ctx, client.ObjectKey{Namespace: "starlord", Name: name}, sa), qt.IsNil)
+				qt.Assert(t, sa.Annotations, qt.DeepEquals, want)
+			}
+
+			cm := &corev1.ConfigMap{}
+			err = k8s.Get(ctx, client.ObjectKey{Namespace: "starlord", Name: TrustPolicyConfigMapName}, cm)
+			if subtest.wantConfigMap == nil {
+				qt.Assert(t, apierrors.IsNotFound(err), qt.IsTrue)
+				return
+			}
+			qt.Assert(t, err, qt.IsNil)
+			qt.Assert(t, cm.Data, qt.DeepEquals, subtest.wantConfigMap)
+			qt.Assert(t, metav1.IsControlledBy(cm, profile()), qt.IsTrue)
+		})
+	}
+}
+
+func TestAWSIAMForServiceAccount_Revoke(t *testing.T) {
+	ctx := context.Background()
+	k8s := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		contributorServiceAccount("starlord", map[string]string{AnnotationAWSRoleARN: testRoleARN}),
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: TrustPolicyConfigMapName, Namespace: "starlord"}},
+	).Build()
+
+	p := NewAWSIAMForServiceAccount(manager.FromClient(k8s))
+	qt.Assert(t, p.Revoke(ctx, profile(), nil), qt.IsNil)
+
+	sa := &corev1.ServiceAccount{}
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKey{Namespace: "starlord", Name: "starlord"}, sa), qt.IsNil)
+	qt.Assert(t, sa.Annotations, qt.HasLen, 0)
+
+	err := k8s.Get(ctx, client.ObjectKey{Namespace: "starlord", Name: TrustPolicyConfigMapName}, &corev1.ConfigMap{})
+	qt.Assert(t, apierrors.IsNotFound(err), qt.IsTrue)
+}
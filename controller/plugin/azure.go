@@ -0,0 +1,80 @@
+package plugin
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/johnhoman/kubeflow-profile-manager/apis/v1alpha1"
+	"github.com/johnhoman/kubeflow-profile-manager/controller/manager"
+)
+
+const (
+	// KindAzureWorkloadIdentity is the plugin kind for Azure AD Workload Identity
+	KindAzureWorkloadIdentity = "AzureWorkloadIdentity"
+
+	// LabelAzureWorkloadIdentityUse opts the pods using a ServiceAccount in to
+	// Azure Workload Identity
+	LabelAzureWorkloadIdentityUse = "azure.workload.identity/use"
+	// AnnotationAzureClientID is the client ID of the Azure AD application or
+	// managed identity a ServiceAccount is federated with
+	AnnotationAzureClientID = "azure.workload.identity/client-id"
+	// AnnotationAzureTenantID is the tenant of the Azure AD application
+	AnnotationAzureTenantID = "azure.workload.identity/tenant-id"
+
+	errAzureClientIDRequired = "clientId is required"
+)
+
+// AzureWorkloadIdentitySpec is the spec of an AzureWorkloadIdentity plugin
+type AzureWorkloadIdentitySpec struct {
+	// ClientID of the Azure AD application or user assigned managed identity
+	ClientID string `json:"clientId"`
+
+	// TenantID of the Azure AD application. The Azure Workload Identity
+	// webhook uses the cluster tenant when this is not set
+	TenantID string `json:"tenantId,omitempty"`
+}
+
+// NewAzureWorkloadIdentity returns the Azure Workload Identity plugin. The
+// plugin labels the profile ServiceAccounts for use with Azure Workload
+// Identity and annotates them with the client and tenant. The federated
+// identity credential is expected to be managed outside of the cluster.
+func NewAzureWorkloadIdentity(mgr manager.Manager) *AzureWorkloadIdentity {
+	return &AzureWorkloadIdentity{client: mgr.GetClient()}
+}
+
+type AzureWorkloadIdentity struct {
+	client client.Client
+}
+
+func (p *AzureWorkloadIdentity) Apply(ctx context.Context, profile *v1alpha1.Profile, raw *runtime.RawExtension) error {
+	spec := &AzureWorkloadIdentitySpec{}
+	if err := decode(raw, spec); err != nil {
+		return err
+	}
+	if spec.ClientID == "" {
+		return errors.New(errAzureClientIDRequired)
+	}
+	return patchServiceAccounts(ctx, p.client, profile, func(sa *corev1.ServiceAccount) {
+		setLabel(sa, LabelAzureWorkloadIdentityUse, "true")
+		setAnnotation(sa, AnnotationAzureClientID, spec.ClientID)
+		if spec.TenantID != "" {
+			setAnnotation(sa, AnnotationAzureTenantID, spec.TenantID)
+		} else {
+			removeAnnotation(sa, AnnotationAzureTenantID)
+		}
+	})
+}
+
+func (p *AzureWorkloadIdentity) Revoke(ctx context.Context, profile *v1alpha1.Profile, _ *runtime.RawExtension) error {
+	return patchServiceAccounts(ctx, p.client, profile, func(sa *corev1.ServiceAccount) {
+		removeLabel(sa, LabelAzureWorkloadIdentityUse)
+		removeAnnotation(sa, AnnotationAzureClientID)
+		removeAnnotation(sa, AnnotationAzureTenantID)
+	})
+}
+
+var _ Plugin = &AzureWorkloadIdentity{}
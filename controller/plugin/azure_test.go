@@ -0,0 +1,106 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/johnhoman/kubeflow-profile-manager/controller/manager"
+)
+
+func TestAzureWorkloadIdentity_Apply(t *testing.T) {
+	cases := map[string]struct {
+		spec            string
+		initObjs        []client.Object
+		wantErr         string
+		wantLabels      map[string]string
+		wantAnnotations map[string]string
+	}{
+		"LabelsAndAnnotatesServiceAccounts": {
+			spec:     `{"clientId":"00000000-0000-0000-0000-000000000001","tenantId":"00000000-0000-0000-0000-000000000002"}`,
+			initObjs: []client.Object{contributorServiceAccount("starlord", nil)},
+			wantLabels: map[string]string{
+				LabelOwnerID:                  "1234",
+				LabelAzureWorkloadIdentityUse: "true",
+			},
+			wantAnnotations: map[string]string{
+				AnnotationAzureClientID: "00000000-0000-0000-0000-000000000001",
+				AnnotationAzureTenantID: "00000000-0000-0000-0000-000000000002",
+			},
+		},
+		"RemovesATenantNoLongerInTheSpec": {
+			spec: `{"clientId":"00000000-0000-0000-0000-000000000001"}`,
+			initObjs: []client.Object{contributorServiceAccount("starlord", map[string]string{
+				AnnotationAzureTenantID: "00000000-0000-0000-0000-000000000002",
+			})},
+			wantLabels: map[string]string{
+				LabelOwnerID:                  "1234",
+				LabelAzureWorkloadIdentityUse: "true",
+			},
+			wantAnnotations: map[string]string{
+				AnnotationAzureClientID: "00000000-0000-0000-0000-000000000001",
+			},
+		},
+		"RequiresAClientID": {
+			spec:       `{"tenantId":"00000000-0000-0000-0000-000000000002"}`,
+			initObjs:   []client.Object{contributorServiceAccount("starlord", nil)},
+			wantErr:    errAzureClientIDRequired,
+			wantLabels: map[string]string{LabelOwnerID: "1234"},
+		},
+	}
+
+	ctx := context.Background()
+	for name, subtest := range cases {
+		t.Run(name, func(t *testing.T) {
+			k8s := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(subtest.initObjs...).Build()
+
+			p := NewAzureWorkloadIdentity(manager.FromClient(k8s))
+			err := p.Apply(ctx, profile(), &runtime.RawExtension{Raw: []byte(subtest.spec)})
+			if subtest.wantErr != "" {
+				qt.Assert(t, err, qt.ErrorMatches, subtest.wantErr)
+			} else {
+				qt.Assert(t, err, qt.IsNil)
+			}
+
+			sa := &corev1.ServiceAccount{}
+			qt.Assert(t, k8s.Get(ctx, client.ObjectKey{Namespace: "starlord", Name: "starlord"}, sa), qt.IsNil)
+			qt.Assert(t, sa.Labels, qt.DeepEquals, subtest.wantLabels)
+			if len(subtest.wantAnnotations) == 0 {
+				qt.Assert(t, sa.Annotations, qt.HasLen, 0)
+			} else {
+				qt.Assert(t, sa.Annotations, qt.DeepEquals, subtest.wantAnnotations)
+			}
+		})
+	}
+}
+
+func TestAzureWorkloadIdentity_Revoke(t *testing.T) {
+	ctx := context.Background()
+	sa := contributorServiceAccount("starlord", map[string]string{
+		AnnotationAzureClientID:   "00000000-0000-0000-0000-000000000001",
+		AnnotationAzureTenantID:   "00000000-0000-0000-0000-000000000002",
+		"owner.kubeflow.org/name": "starlord@guardians.net",
+	})
+	sa.Labels[LabelAzureWorkloadIdentityUse] = "true"
+	k8s := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(sa).Build()
+
+	p := NewAzureWorkloadIdentity(manager.FromClient(k8s))
+	qt.Assert(t, p.Revoke(ctx, profile(), nil), qt.IsNil)
+
+	got := &corev1.ServiceAccount{}
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKey{Namespace: "starlord", Name: "starlord"}, got), qt.IsNil)
+	qt.Assert(t, got.ObjectMeta, qt.CmpEquals(), metav1.ObjectMeta{
+		Name:            "starlord",
+		Namespace:       "starlord",
+		ResourceVersion: got.ResourceVersion,
+		Labels:          map[string]string{LabelOwnerID: "1234"},
+		Annotations:     map[string]string{"owner.kubeflow.org/name": "starlord@guardians.net"},
+	})
+}
@@ -0,0 +1,64 @@
+package plugin
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/johnhoman/kubeflow-profile-manager/apis/v1alpha1"
+	"github.com/johnhoman/kubeflow-profile-manager/controller/manager"
+)
+
+const (
+	// KindWorkloadIdentity is the upstream plugin kind for GCP Workload Identity
+	KindWorkloadIdentity = "WorkloadIdentity"
+
+	// AnnotationGCPServiceAccount binds a Kubernetes ServiceAccount to a
+	// GCP service account
+	AnnotationGCPServiceAccount = "iam.gke.io/gcp-service-account"
+
+	errGCPServiceAccountRequired = "gcpServiceAccount is required"
+)
+
+// WorkloadIdentitySpec is the spec of a WorkloadIdentity plugin
+type WorkloadIdentitySpec struct {
+	// GCPServiceAccount is the email of the GCP service account the profile
+	// service accounts act as
+	GCPServiceAccount string `json:"gcpServiceAccount"`
+}
+
+// NewWorkloadIdentity returns the GCP Workload Identity plugin. The plugin
+// annotates the profile ServiceAccounts with the GCP service account. The
+// roles/iam.workloadIdentityUser binding on the GCP service account is
+// expected to be managed outside of the cluster.
+func NewWorkloadIdentity(mgr manager.Manager) *WorkloadIdentity {
+	return &WorkloadIdentity{client: mgr.GetClient()}
+}
+
+type WorkloadIdentity struct {
+	client client.Client
+}
+
+func (p *WorkloadIdentity) Apply(ctx context.Context, profile *v1alpha1.Profile, raw *runtime.RawExtension) error {
+	spec := &WorkloadIdentitySpec{}
+	if err := decode(raw, spec); err != nil {
+		return err
+	}
+	if spec.GCPServiceAccount == "" {
+		return errors.New(errGCPServiceAccountRequired)
+	}
+	return patchServiceAccounts(ctx, p.client, profile, func(sa *corev1.ServiceAccount) {
+		setAnnotation(sa, AnnotationGCPServiceAccount, spec.GCPServiceAccount)
+	})
+}
+
+func (p *WorkloadIdentity) Revoke(ctx context.Context, profile *v1alpha1.Profile, _ *runtime.RawExtension) error {
+	return patchServiceAccounts(ctx, p.client, profile, func(sa *corev1.ServiceAccount) {
+		removeAnnotation(sa, AnnotationGCPServiceAccount)
+	})
+}
+
+var _ Plugin = &WorkloadIdentity{}
@@ -0,0 +1,111 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/johnhoman/kubeflow-profile-manager/apis/v1alpha1"
+	"github.com/johnhoman/kubeflow-profile-manager/controller/manager"
+)
+
+func profile() *v1alpha1.Profile {
+	return &v1alpha1.Profile{ObjectMeta: metav1.ObjectMeta{Name: "starlord"}}
+}
+
+func contributorServiceAccount(name string, annotations map[string]string) *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "starlord",
+			Labels:      map[string]string{LabelOwnerID: "1234"},
+			Annotations: annotations,
+		},
+	}
+}
+
+func TestWorkloadIdentity_Apply(t *testing.T) {
+	cases := map[string]struct {
+		spec     string
+		initObjs []client.Object
+		wantErr  string
+		want     map[string]map[string]string
+	}{
+		"AnnotatesContributorServiceAccounts": {
+			spec: `{"gcpServiceAccount":"starlord@guardians.iam.gserviceaccount.com"}`,
+			initObjs: []client.Object{
+				contributorServiceAccount("starlord", nil),
+				contributorServiceAccount("gamora", map[string]string{"owner.kubeflow.org/name": "gamora@guardians.net"}),
+				&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "starlord"}},
+			},
+			want: map[string]map[string]string{
+				"starlord": {AnnotationGCPServiceAccount: "starlord@guardians.iam.gserviceaccount.com"},
+				"gamora": {
+					AnnotationGCPServiceAccount: "starlord@guardians.iam.gserviceaccount.com",
+					"owner.kubeflow.org/name":   "gamora@guardians.net",
+				},
+				"default": nil,
+			},
+		},
+		"ReplacesAnExistingGCPServiceAccount": {
+			spec: `{"gcpServiceAccount":"starlord@guardians.iam.gserviceaccount.com"}`,
+			initObjs: []client.Object{
+				contributorServiceAccount("starlord", map[string]string{AnnotationGCPServiceAccount: "yondu@ravagers.iam.gserviceaccount.com"}),
+			},
+			want: map[string]map[string]string{
+				"starlord": {AnnotationGCPServiceAccount: "starlord@guardians.iam.gserviceaccount.com"},
+			},
+		},
+		"RequiresAGCPServiceAccount": {
+			spec:     `{}`,
+			initObjs: []client.Object{contributorServiceAccount("starlord", nil)},
+			wantErr:  errGCPServiceAccountRequired,
+			want:     map[string]map[string]string{"starlord": nil},
+		},
+	}
+
+	ctx := context.Background()
+	for name, subtest := range cases {
+		t.Run(name, func(t *testing.T) {
+			k8s := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(subtest.initObjs...).Build()
+
+			p := NewWorkloadIdentity(manager.FromClient(k8s))
+			err := p.Apply(ctx, profile(), &runtime.RawExtension{Raw: []byte(subtest.spec)})
+			if subtest.wantErr != "" {
+				qt.Assert(t, err, qt.ErrorMatches, subtest.wantErr)
+			} else {
+				qt.Assert(t, err, qt.IsNil)
+			}
+
+			for name, want := range subtest.want {
+				sa := &corev1.ServiceAccount{}
+				qt.Assert(t, k8s.Get(ctx, client.ObjectKey{Namespace: "starlord", Name: name}, sa), qt.IsNil)
+				qt.Assert(t, sa.Annotations, qt.DeepEquals, want)
+			}
+		})
+	}
+}
+
+func TestWorkloadIdentity_Revoke(t *testing.T) {
+	ctx := context.Background()
+	k8s := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		contributorServiceAccount("starlord", map[string]string{
+			AnnotationGCPServiceAccount: "starlord@guardians.iam.gserviceaccount.com",
+			"owner.kubeflow.org/name":   "starlord@guardians.net",
+		}),
+	).Build()
+
+	p := NewWorkloadIdentity(manager.FromClient(k8s))
+	qt.Assert(t, p.Revoke(ctx, profile(), nil), qt.IsNil)
+
+	sa := &corev1.ServiceAccount{}
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKey{Namespace: "starlord", Name: "starlord"}, sa), qt.IsNil)
+	qt.Assert(t, sa.Annotations, qt.DeepEquals, map[string]string{"owner.kubeflow.org/name": "starlord@guardians.net"})
+}
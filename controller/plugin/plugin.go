@@ -0,0 +1,109 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/johnhoman/kubeflow-profile-manager/apis/v1alpha1"
+)
+
+const (
+	errDecodeSpec          = "failed to decode plugin spec"
+	errListServiceAccounts = "failed to list profile service accounts"
+	errPatchServiceAccount = "failed to patch service account"
+
+	// LabelOwnerID is set on every ServiceAccount created for a profile
+	// contributor
+	LabelOwnerID = "owner.kubeflow.org/id"
+)
+
+// +kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get;list;watch;patch
+
+// Plugin grants a profile access to resources outside of the cluster. Apply
+// is called on every profile reconcile and must be idempotent. Revoke is
+// called before the profile is deleted, and when the plugin is removed from
+// the profile spec. The spec passed to Revoke is nil when the plugin was
+// removed from the profile spec.
+type Plugin interface {
+	// Apply grants the access described by the plugin spec
+	Apply(ctx context.Context, profile *v1alpha1.Profile, spec *runtime.RawExtension) error
+
+	// Revoke removes the access granted by Apply
+	Revoke(ctx context.Context, profile *v1alpha1.Profile, spec *runtime.RawExtension) error
+}
+
+// decode unmarshals the raw plugin spec into obj. A missing spec leaves obj
+// unchanged
+func decode(spec *runtime.RawExtension, obj interface{}) error {
+	if spec == nil || len(spec.Raw) == 0 {
+		return nil
+	}
+	return errors.Wrap(json.Unmarshal(spec.Raw, obj), errDecodeSpec)
+}
+
+// serviceAccounts returns the contributor ServiceAccounts in the profile namespaces
+func serviceAccounts(ctx context.Context, cli client.Client, profile *v1alpha1.Profile) ([]corev1.ServiceAccount, error) {
+	items := make([]corev1.ServiceAccount, 0)
+	for _, namespace := range profile.TargetNamespaces() {
+		serviceAccountList := &corev1.ServiceAccountList{}
+		err := cli.List(ctx, serviceAccountList, client.InNamespace(namespace.Name), client.HasLabels{LabelOwnerID})
+		if err != nil {
+			return nil, errors.Wrap(err, errListServiceAccounts)
+		}
+		items = append(items, serviceAccountList.Items...)
+	}
+	return items, nil
+}
+
+// patchServiceAccounts applies fn to every contributor ServiceAccount in the
+// profile namespaces and patches the ServiceAccounts that changed
+func patchServiceAccounts(ctx context.Context, cli client.Client, profile *v1alpha1.Profile, fn func(sa *corev1.ServiceAccount)) error {
+	items, err := serviceAccounts(ctx, cli, profile)
+	if err != nil {
+		return err
+	}
+	for k := range items {
+		sa := &items[k]
+		patch := client.MergeFrom(sa.DeepCopy())
+		fn(sa)
+		if err := cli.Patch(ctx, sa, patch); client.IgnoreNotFound(err) != nil {
+			return errors.Wrap(err, errPatchServiceAccount)
+		}
+	}
+	return nil
+}
+
+func setAnnotation(o client.Object, key, value string) {
+	annotations := o.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[key] = value
+	o.SetAnnotations(annotations)
+}
+
+func removeAnnotation(o client.Object, key string) {
+	annotations := o.GetAnnotations()
+	delete(annotations, key)
+	o.SetAnnotations(annotations)
+}
+
+func setLabel(o client.Object, key, value string) {
+	labels := o.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[key] = value
+	o.SetLabels(labels)
+}
+
+func removeLabel(o client.Object, key string) {
+	labels := o.GetLabels()
+	delete(labels, key)
+	o.SetLabels(labels)
+}
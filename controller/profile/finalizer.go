@@ -0,0 +1,364 @@
+package profile
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/pkg/errors"
+	istionetworking "istio.io/client-go/pkg/apis/networking/v1beta1"
+	istiosecurity "istio.io/client-go/pkg/apis/security/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/johnhoman/kubeflow-profile-manager/apis/v1alpha1"
+)
+
+const (
+	errDeleteContributors = "failed to delete contributors"
+	errDeleteNamespace    = "failed to delete namespace"
+	errListPods           = "failed to list pods"
+	errOrphanNamespace    = "failed to orphan namespace"
+
+	errFmtOrphan               = "failed to orphan %T"
+	errFmtOrphanTemplateObject = "failed to orphan template object %s %s"
+
+	errFmtDeleteWorkloads = "failed to delete %T workloads"
+
+	msgDeletionProtected = "deletion is blocked by the " + v1alpha1.AnnotationDeletionProtection + " annotation"
+	msgRemovalProtected  = "namespace removal is blocked by the " + v1alpha1.AnnotationDeletionProtection + " annotation"
+	msgTerminating       = "profile is being deleted"
+
+	reasonDeletionProtected event.Reason = "DeletionProtected"
+)
+
+// +kubebuilder:rbac:groups=kubeflow.org,resources=contributors,verbs=delete;deletecollection
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=list;delete;deletecollection
+// +kubebuilder:rbac:groups=apps,resources=deployments;statefulsets;daemonsets;replicasets,verbs=delete;deletecollection
+// +kubebuilder:rbac:groups=batch,resources=jobs;cronjobs,verbs=delete;deletecollection
+
+// teardownPollInterval is how often teardown checks whether workloads and the
+// namespace are gone
+var teardownPollInterval = 5 * time.Second
+
+// workloads are deleted from the profile namespace before the namespace. Pods
+// are deleted last so that controllers don't replace them.
+var workloads = []client.Object{
+	&batchv1.CronJob{},
+	&batchv1.Job{},
+	&appsv1.Deployment{},
+	&appsv1.StatefulSet{},
+	&appsv1.DaemonSet{},
+	&appsv1.ReplicaSet{},
+	&corev1.Pod{},
+}
+
+// finalize tears down a deleted profile in order. Access granted to the
+// profile is revoked, then workloads in the profile namespaces are deleted,
+// then the namespaces are deleted. The profile finalizer is removed once the
+// namespaces are gone. With the Orphan deletion policy the namespace is orphaned
+// instead of deleted. Teardown progress is recorded in the Terminating
+// condition.
+func (r *Reconciler) finalize(ctx context.Context, profile *v1alpha1.Profile) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(profile, Finalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	patch := client.MergeFrom(profile.DeepCopy())
+	result, done, err := r.teardown(ctx, profile)
+	if err != nil {
+		r.recorder.Event(profile, event.Warning(reasonReconcileError, err))
+		profile.Status.SetConditions(xpv1.Condition{
+			Type:               v1alpha1.TypeTerminating,
+			Status:             corev1.ConditionTrue,
+			Reason:             v1alpha1.ReasonReconcileError,
+			Message:            err.Error(),
+			LastTransitionTime: metav1.Now(),
+		})
+	}
+	profile.Status.SetConditions(synced(err), xpv1.Unavailable().WithMessage(msgTerminating))
+	profile.Status.ObservedGeneration = profile.Generation
+	if err := r.client.Status().Patch(ctx, profile, patch); client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, errors.Wrap(err, errUpdateStatus)
+	}
+	if err != nil || !done {
+		return result, err
+	}
+
+	patch = client.MergeFrom(profile.DeepCopy())
+	controllerutil.RemoveFinalizer(profile, Finalizer)
+	err = r.client.Patch(ctx, profile, patch)
+	return ctrl.Result{}, errors.Wrap(client.IgnoreNotFound(err), errRemoveFinalizer)
+}
+
+// teardown runs each teardown step in order. It returns true once every step
+// is complete
+func (r *Reconciler) teardown(ctx context.Context, profile *v1alpha1.Profile) (ctrl.Result, bool, error) {
+	if deletionProtected(profile) {
+		if profile.Status.GetCondition(v1alpha1.TypeTerminating).Reason != v1alpha1.ReasonDeletionProtected {
+			r.recorder.Event(profile, event.Warning(reasonDeletionProtected, errors.New(msgDeletionProtected)))
+		}
+		setTerminating(profile, v1alpha1.ReasonDeletionProtected, msgDeletionProtected)
+		return ctrl.Result{}, false, nil
+	}
+
+	// Namespaces are only torn down if they belong to the profile. A namespace
+	// the profile refused to adopt is left alone.
+	namespaces, err := r.ownedNamespaces(ctx, profile)
+	if err != nil {
+		return ctrl.Result{}, false, err
+	}
+
+	if profile.Spec.DeletionPolicy == v1alpha1.DeletionPolicyOrphan {
+		setTerminating(profile, v1alpha1.ReasonOrphaning, fmt.Sprintf("orphaning namespace %q", profile.TargetNamespace()))
+		if err := r.revokeAccess(ctx, profile, nil); err != nil {
+			return ctrl.Result{}, false, err
+		}
+		for _, namespace := range namespaces {
+			if err := r.orphan(ctx, profile, namespace); err != nil {
+				return ctrl.Result{}, false, err
+			}
+		}
+		return ctrl.Result{}, true, nil
+	}
+
+	setTerminating(profile, v1alpha1.ReasonRevokingAccess, "revoking access to the profile")
+	if err := r.revokeAccess(ctx, profile, namespaces); err != nil {
+		return ctrl.Result{}, false, err
+	}
+	if len(namespaces) == 0 {
+		return ctrl.Result{}, true, nil
+	}
+
+	done, err := r.deleteNamespaces(ctx, namespaces, func(reason xpv1.ConditionReason, msg string) {
+		setTerminating(profile, reason, msg)
+	})
+	if err != nil || done {
+		return ctrl.Result{}, done, err
+	}
+	return ctrl.Result{RequeueAfter: teardownPollInterval}, false, nil
+}
+
+// deleteNamespaces deletes the workloads in the namespaces, then the
+// namespaces. It returns true once the namespaces are gone, and otherwise
+// reports what it is waiting for to progress.
+func (r *Reconciler) deleteNamespaces(ctx context.Context, namespaces []*corev1.Namespace, progress func(reason xpv1.ConditionReason, msg string)) (bool, error) {
+	progress(v1alpha1.ReasonDeletingWorkloads, "deleting workloads in the profile namespace")
+	remaining := 0
+	for _, namespace := range namespaces {
+		n, err := r.deleteWorkloads(ctx, namespace.Name)
+		if err != nil {
+			return false, err
+		}
+		remaining += n
+	}
+	if remaining > 0 {
+		progress(v1alpha1.ReasonDeletingWorkloads, fmt.Sprintf("waiting for %d pods to terminate", remaining))
+		return false, nil
+	}
+
+	for _, namespace := range namespaces {
+		if namespace.DeletionTimestamp == nil {
+			if err := r.client.Delete(ctx, namespace); client.IgnoreNotFound(err) != nil {
+				return false, errors.Wrap(err, errDeleteNamespace)
+			}
+		}
+	}
+	for _, namespace := range namespaces {
+		err := r.client.Get(ctx, client.ObjectKeyFromObject(namespace), namespace)
+		if client.IgnoreNotFound(err) != nil {
+			return false, errors.Wrap(err, errDeleteNamespace)
+		}
+		if err == nil {
+			progress(v1alpha1.ReasonDeletingNamespace, fmt.Sprintf("waiting for namespace %q to be deleted", namespace.Name))
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// ownedNamespaces returns the namespaces managed by the profile that exist and
+// are controlled by the profile
+func (r *Reconciler) ownedNamespaces(ctx context.Context, profile *v1alpha1.Profile) ([]*corev1.Namespace, error) {
+	namespaces := make([]*corev1.Namespace, 0)
+	for _, name := range managedNamespaces(profile) {
+		namespace := &corev1.Namespace{}
+		err := r.client.Get(ctx, client.ObjectKey{Name: name}, namespace)
+		if client.IgnoreNotFound(err) != nil {
+			return nil, errors.Wrap(err, errDeleteNamespace)
+		}
+		if err == nil && metav1.IsControlledBy(namespace, profile) {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces, nil
+}
+
+// orphan removes the profile controller reference from the namespace and the
+// resource quotas, limit ranges, config maps, policies and template objects
+// the profile manages in it, so that they are not garbage collected with the
+// profile. The namespace owner annotation is removed.
+func (r *Reconciler) orphan(ctx context.Context, profile *v1alpha1.Profile, namespace *corev1.Namespace) error {
+	for _, list := range orphanedLists() {
+		err := r.client.List(ctx, list,
+			client.InNamespace(namespace.Name),
+			client.MatchingLabels{"app.kubernetes.io/part-of": "kubeflow-profile"},
+		)
+		if meta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, errFmtOrphan, list)
+		}
+		err = meta.EachListItem(list, func(o runtime.Object) error {
+			obj := o.(client.Object)
+			patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+			if !removeOwnerReference(obj, profile) {
+				return nil
+			}
+			return client.IgnoreNotFound(r.client.Patch(ctx, obj, patch))
+		})
+		if err != nil {
+			return errors.Wrapf(err, errFmtOrphan, list)
+		}
+	}
+
+	if err := r.orphanTemplateObjects(ctx, profile, namespace.Name); err != nil {
+		return err
+	}
+
+	patch := client.MergeFrom(namespace.DeepCopy())
+	removeOwnerReference(namespace, profile)
+	annotations := namespace.GetAnnotations()
+	delete(annotations, "owner")
+	namespace.SetAnnotations(annotations)
+	return errors.Wrap(client.IgnoreNotFound(r.client.Patch(ctx, namespace, patch)), errOrphanNamespace)
+}
+
+// orphanTemplateObjects removes the profile controller reference from the
+// template objects in the namespace
+func (r *Reconciler) orphanTemplateObjects(ctx context.Context, profile *v1alpha1.Profile, namespace string) error {
+	for _, ref := range profile.Status.TemplateObjects {
+		if ref.Namespace != namespace {
+			continue
+		}
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(ref.APIVersion)
+		obj.SetKind(ref.Kind)
+		err := r.client.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, obj)
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, errFmtOrphanTemplateObject, ref.Kind, ref.Name)
+		}
+		patch := client.MergeFrom(obj.DeepCopy())
+		if !removeOwnerReference(obj, profile) {
+			continue
+		}
+		if err := r.client.Patch(ctx, obj, patch); client.IgnoreNotFound(err) != nil {
+			return errors.Wrapf(err, errFmtOrphanTemplateObject, ref.Kind, ref.Name)
+		}
+	}
+	return nil
+}
+
+// orphanedLists returns the lists of resources that are orphaned with the
+// profile namespace
+func orphanedLists() []client.ObjectList {
+	return []client.ObjectList{
+		&corev1.ResourceQuotaList{},
+		&corev1.LimitRangeList{},
+		&corev1.ConfigMapList{},
+		&istiosecurity.AuthorizationPolicyList{},
+		&networkingv1.NetworkPolicyList{},
+		&istiosecurity.PeerAuthenticationList{},
+		&istionetworking.SidecarList{},
+	}
+}
+
+// removeOwnerReference removes the owner references to the profile from obj.
+// It returns true if a reference was removed
+func removeOwnerReference(obj client.Object, profile *v1alpha1.Profile) bool {
+	refs := obj.GetOwnerReferences()
+	kept := make([]metav1.OwnerReference, 0, len(refs))
+	for _, ref := range refs {
+		if ref.UID == profile.UID {
+			continue
+		}
+		kept = append(kept, ref)
+	}
+	if len(kept) == len(refs) {
+		return false
+	}
+	if len(kept) == 0 {
+		kept = nil
+	}
+	obj.SetOwnerReferences(kept)
+	return true
+}
+
+// revokeAccess revokes each plugin in the profile spec and deletes the
+// contributors in the profile namespaces
+func (r *Reconciler) revokeAccess(ctx context.Context, profile *v1alpha1.Profile, namespaces []*corev1.Namespace) error {
+	for _, spec := range profile.Spec.Plugins {
+		p, ok := r.pluginKinds[spec.Kind]
+		if !ok {
+			continue
+		}
+		if err := p.Revoke(ctx, profile, spec.Spec); err != nil {
+			return errors.Wrapf(err, errFmtRevokePlugin, spec.Kind)
+		}
+	}
+	for _, namespace := range namespaces {
+		err := r.client.DeleteAllOf(ctx, &v1alpha1.Contributor{}, client.InNamespace(namespace.Name))
+		if err != nil {
+			return errors.Wrap(err, errDeleteContributors)
+		}
+	}
+	return nil
+}
+
+// deleteWorkloads deletes the workloads in a profile namespace and returns
+// the number of pods that have not terminated yet
+func (r *Reconciler) deleteWorkloads(ctx context.Context, namespace string) (int, error) {
+	for _, obj := range workloads {
+		err := r.client.DeleteAllOf(ctx, obj,
+			client.InNamespace(namespace),
+			client.PropagationPolicy(metav1.DeletePropagationBackground),
+		)
+		if err != nil {
+			return 0, errors.Wrapf(err, errFmtDeleteWorkloads, obj)
+		}
+	}
+	// pods are listed from the API server so that counting them doesn't
+	// start a cluster wide pod informer
+	podList := &metav1.PartialObjectMetadataList{}
+	podList.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("PodList"))
+	if err := r.reader.List(ctx, podList, client.InNamespace(namespace)); err != nil {
+		return 0, errors.Wrap(err, errListPods)
+	}
+	return len(podList.Items), nil
+}
+
+func setTerminating(profile *v1alpha1.Profile, reason xpv1.ConditionReason, msg string) {
+	profile.Status.SetConditions(xpv1.Condition{
+		Type:               v1alpha1.TypeTerminating,
+		Status:             corev1.ConditionTrue,
+		Reason:             reason,
+		Message:            msg,
+		LastTransitionTime: metav1.Now(),
+	})
+}
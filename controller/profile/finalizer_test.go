@@ -0,0 +1,364 @@
+package profile
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	qt "github.com/frankban/quicktest"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/pkg/errors"
+	istionetworking "istio.io/client-go/pkg/apis/networking/v1beta1"
+	istiosecurity "istio.io/client-go/pkg/apis/security/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/johnhoman/kubeflow-profile-manager/apis/v1alpha1"
+	"github.com/johnhoman/kubeflow-profile-manager/controller/manager"
+)
+
+func ownedNamespace() *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "starlord",
+			Annotations: map[string]string{"owner": "starlord@guardians.net"},
+			OwnerReferences: []metav1.OwnerReference{{
+				Name:               "starlord",
+				Kind:               "Profile",
+				APIVersion:         "kubeflow.org/v1alpha1",
+				UID:                "1234",
+				Controller:         pointer.Bool(true),
+				BlockOwnerDeletion: pointer.Bool(true),
+			}},
+		},
+	}
+}
+
+func TestReconciler_AddsFinalizer(t *testing.T) {
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	profile := &v1alpha1.Profile{
+		ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+		Spec: v1alpha1.ProfileSpec{
+			Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+		},
+	}
+	k8s := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(profile).Build()
+
+	r := NewReconciler(manager.FromClient(k8s), WithDefaultNamespaceReconcileFunc())
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(profile)})
+	qt.Assert(t, err, qt.IsNil)
+
+	got := &v1alpha1.Profile{}
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(profile), got), qt.IsNil)
+	qt.Assert(t, got.Finalizers, qt.DeepEquals, []string{Finalizer})
+	qt.Assert(t, got.Status.GetCondition(v1alpha1.TypeReady).Status, qt.Equals, corev1.ConditionTrue)
+}
+
+func TestReconciler_Finalize(t *testing.T) {
+	cases := map[string]struct {
+		annotations map[string]string
+		finalizers  []string
+		initObjs    []client.Object
+		pluginErr   error
+
+		wantErr         string
+		wantResult      ctrl.Result
+		wantRevoked     []string
+		wantTerminating *xpv1.Condition
+		// wantDeleted is true when the profile is deleted after its
+		// finalizer is removed
+		wantDeleted   bool
+		wantNamespace bool
+		wantObjs      []client.Object
+		wantGone      []client.Object
+	}{
+		"DeletesTheNamespaceAndRemovesTheFinalizer": {
+			finalizers: []string{Finalizer},
+			initObjs: []client.Object{
+				ownedNamespace(),
+				&v1alpha1.Contributor{ObjectMeta: metav1.ObjectMeta{Name: "gamora", Namespace: "starlord"}},
+				&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "starlord"}},
+			},
+			wantRevoked: []string{`{"name":"gamora"}`},
+			wantDeleted: true,
+			wantGone: []client.Object{
+				&v1alpha1.Contributor{ObjectMeta: metav1.ObjectMeta{Name: "gamora", Namespace: "starlord"}},
+				&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "starlord"}},
+			},
+		},
+		"WaitsForPodsToTerminate": {
+			finalizers: []string{Finalizer},
+			initObjs: []client.Object{
+				ownedNamespace(),
+				&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+					Name:       "notebook-0",
+					Namespace:  "starlord",
+					Finalizers: []string{"kubernetes.io/test"},
+				}},
+			},
+			wantResult:  ctrl.Result{RequeueAfter: teardownPollInterval},
+			wantRevoked: []string{`{"name":"gamora"}`},
+			wantTerminating: &xpv1.Condition{
+				Type:    v1alpha1.TypeTerminating,
+				Status:  corev1.ConditionTrue,
+				Reason:  v1alpha1.ReasonDeletingWorkloads,
+				Message: "waiting for 1 pods to terminate",
+			},
+			wantNamespace: true,
+		},
+		"LeavesANamespaceNotOwnedByTheProfile": {
+			finalizers: []string{Finalizer},
+			initObjs: []client.Object{
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "starlord"}},
+				&v1alpha1.Contributor{ObjectMeta: metav1.ObjectMeta{Name: "gamora", Namespace: "starlord"}},
+			},
+			wantRevoked:   []string{`{"name":"gamora"}`},
+			wantDeleted:   true,
+			wantNamespace: true,
+			wantObjs: []client.Object{
+				&v1alpha1.Contributor{ObjectMeta: metav1.ObjectMeta{Name: "gamora", Namespace: "starlord"}},
+			},
+		},
+		"BlocksDeletionWithDeletionProtection": {
+			annotations: map[string]string{v1alpha1.AnnotationDeletionProtection: "true"},
+			finalizers:  []string{Finalizer},
+			initObjs:    []client.Object{ownedNamespace()},
+			wantTerminating: &xpv1.Condition{
+				Type:    v1alpha1.TypeTerminating,
+				Status:  corev1.ConditionTrue,
+				Reason:  v1alpha1.ReasonDeletionProtected,
+				Message: msgDeletionProtected,
+			},
+			wantNamespace: true,
+		},
+		"KeepsTheFinalizerWhenRevokeFails": {
+			finalizers:  []string{Finalizer},
+			initObjs:    []client.Object{ownedNamespace()},
+			pluginErr:   errors.New("access denied"),
+			wantErr:     "failed to revoke plugin Fake: access denied",
+			wantRevoked: []string{`{"name":"gamora"}`},
+			wantTerminating: &xpv1.Condition{
+				Type:    v1alpha1.TypeTerminating,
+				Status:  corev1.ConditionTrue,
+				Reason:  v1alpha1.ReasonReconcileError,
+				Message: "failed to revoke plugin Fake: access denied",
+			},
+			wantNamespace: true,
+		},
+		"IgnoresAProfileWithoutTheFinalizer": {
+			finalizers:    []string{"kubernetes.io/other"},
+			initObjs:      []client.Object{ownedNamespace()},
+			wantNamespace: true,
+		},
+	}
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	for name, subtest := range cases {
+		t.Run(name, func(t *testing.T) {
+			now := metav1.NewTime(time.Now())
+			profile := &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "starlord",
+					UID:               "1234",
+					Annotations:       subtest.annotations,
+					DeletionTimestamp: &now,
+					Finalizers:        subtest.finalizers,
+				},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+					Plugins: []v1alpha1.Plugin{{
+						TypeMeta: metav1.TypeMeta{Kind: "Fake"},
+						Spec:     &runtime.RawExtension{Raw: []byte(`{"name":"gamora"}`)},
+					}},
+				},
+			}
+			k8s := fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(profile).
+				WithObjects(subtest.initObjs...).
+				Build()
+
+			p := &fakePlugin{err: subtest.pluginErr}
+			r := NewReconciler(manager.FromClient(k8s),
+				WithDefaultNamespaceReconcileFunc(),
+				WithPluginsEnabled(),
+				WithPlugin("Fake", p),
+				WithRecorder(event.NewAPIRecorder(record.NewFakeRecorder(100))),
+			)
+			res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(profile)})
+			if subtest.wantErr != "" {
+				qt.Assert(t, err, qt.ErrorMatches, subtest.wantErr)
+			} else {
+				qt.Assert(t, err, qt.IsNil)
+			}
+			qt.Assert(t, res, qt.Equals, subtest.wantResult)
+			qt.Assert(t, p.applied, qt.IsNil)
+			qt.Assert(t, p.revoked, qt.DeepEquals, subtest.wantRevoked)
+
+			got := &v1alpha1.Profile{}
+			err = k8s.Get(ctx, client.ObjectKeyFromObject(profile), got)
+			if subtest.wantDeleted {
+				qt.Assert(t, apierrors.IsNotFound(err), qt.IsTrue)
+			} else {
+				qt.Assert(t, err, qt.IsNil)
+				qt.Assert(t, got.Finalizers, qt.DeepEquals, subtest.finalizers)
+			}
+			if subtest.wantTerminating != nil {
+				qt.Assert(t, got.Status.GetCondition(v1alpha1.TypeTerminating), qt.CmpEquals(
+					cmpopts.IgnoreFields(xpv1.Condition{}, "LastTransitionTime"),
+				), *subtest.wantTerminating)
+				qt.Assert(t, got.Status.GetCondition(v1alpha1.TypeReady).Status, qt.Equals, corev1.ConditionFalse)
+			}
+
+			err = k8s.Get(ctx, client.ObjectKey{Name: "starlord"}, &corev1.Namespace{})
+			if subtest.wantNamespace {
+				qt.Assert(t, err, qt.IsNil)
+			} else {
+				qt.Assert(t, apierrors.IsNotFound(err), qt.IsTrue)
+			}
+			for _, obj := range subtest.wantObjs {
+				qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(obj), obj), qt.IsNil)
+			}
+			for _, obj := range subtest.wantGone {
+				err := k8s.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+				qt.Assert(t, apierrors.IsNotFound(err), qt.IsTrue, qt.Commentf("%T %s", obj, obj.GetName()))
+			}
+		})
+	}
+}
+
+func TestReconciler_FinalizeOrphansTheNamespace(t *testing.T) {
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+	qt.Assert(t, istiosecurity.AddToScheme(scheme.Scheme), qt.IsNil)
+	qt.Assert(t, istionetworking.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	now := metav1.NewTime(time.Now())
+	profile := &v1alpha1.Profile{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "starlord",
+			UID:               "1234",
+			DeletionTimestamp: &now,
+			Finalizers:        []string{Finalizer},
+		},
+		Spec: v1alpha1.ProfileSpec{
+			Owner:          rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+			DeletionPolicy: v1alpha1.DeletionPolicyOrphan,
+			Plugins: []v1alpha1.Plugin{{
+				TypeMeta: metav1.TypeMeta{Kind: "Fake"},
+				Spec:     &runtime.RawExtension{Raw: []byte(`{"name":"gamora"}`)},
+			}},
+		},
+		Status: v1alpha1.ProfileStatus{
+			TemplateObjects: []corev1.ObjectReference{{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Namespace:  "starlord",
+				Name:       "team-dashboard",
+			}},
+		},
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "team-dashboard",
+			Namespace:       "starlord",
+			OwnerReferences: ownedNamespace().OwnerReferences,
+		},
+	}
+	quota := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "kf-resource-quota",
+			Namespace:       "starlord",
+			Labels:          map[string]string{"app.kubernetes.io/part-of": "kubeflow-profile"},
+			OwnerReferences: ownedNamespace().OwnerReferences,
+		},
+	}
+	policy := &istiosecurity.AuthorizationPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "control-plane-access",
+			Namespace:       "starlord",
+			Labels:          map[string]string{"app.kubernetes.io/part-of": "kubeflow-profile"},
+			OwnerReferences: ownedNamespace().OwnerReferences,
+		},
+	}
+	sidecar := &istionetworking.Sidecar{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "kf-sidecar",
+			Namespace:       "starlord",
+			Labels:          map[string]string{"app.kubernetes.io/part-of": "kubeflow-profile"},
+			OwnerReferences: ownedNamespace().OwnerReferences,
+		},
+	}
+	limitRange := &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "kf-limit-range",
+			Namespace:       "starlord",
+			Labels:          map[string]string{"app.kubernetes.io/part-of": "kubeflow-profile"},
+			OwnerReferences: ownedNamespace().OwnerReferences,
+		},
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "aws-iam-trust-policy",
+			Namespace:       "starlord",
+			Labels:          map[string]string{"app.kubernetes.io/part-of": "kubeflow-profile"},
+			OwnerReferences: ownedNamespace().OwnerReferences,
+		},
+	}
+	contributor := &v1alpha1.Contributor{ObjectMeta: metav1.ObjectMeta{Name: "gamora", Namespace: "starlord"}}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "notebook-0", Namespace: "starlord"}}
+	k8s := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(profile, ownedNamespace(), quota, limitRange, policy, sidecar, configMap, deployment, contributor, pod).
+		Build()
+
+	p := &fakePlugin{}
+	r := NewReconciler(manager.FromClient(k8s),
+		WithDefaultNamespaceReconcileFunc(),
+		WithPluginsEnabled(),
+		WithPlugin("Fake", p),
+		WithRecorder(event.NewAPIRecorder(record.NewFakeRecorder(100))),
+	)
+	res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(profile)})
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, res, qt.Equals, ctrl.Result{})
+	qt.Assert(t, p.revoked, qt.DeepEquals, []string{`{"name":"gamora"}`})
+
+	err = k8s.Get(ctx, client.ObjectKeyFromObject(profile), &v1alpha1.Profile{})
+	qt.Assert(t, apierrors.IsNotFound(err), qt.IsTrue)
+
+	namespace := &corev1.Namespace{}
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKey{Name: "starlord"}, namespace), qt.IsNil)
+	qt.Assert(t, namespace.OwnerReferences, qt.HasLen, 0)
+	qt.Assert(t, namespace.Annotations, qt.HasLen, 0)
+
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(quota), quota), qt.IsNil)
+	qt.Assert(t, quota.OwnerReferences, qt.HasLen, 0)
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(limitRange), limitRange), qt.IsNil)
+	qt.Assert(t, limitRange.OwnerReferences, qt.HasLen, 0)
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(policy), policy), qt.IsNil)
+	qt.Assert(t, policy.OwnerReferences, qt.HasLen, 0)
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(sidecar), sidecar), qt.IsNil)
+	qt.Assert(t, sidecar.OwnerReferences, qt.HasLen, 0)
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(configMap), configMap), qt.IsNil)
+	qt.Assert(t, configMap.OwnerReferences, qt.HasLen, 0)
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(deployment), deployment), qt.IsNil)
+	qt.Assert(t, deployment.OwnerReferences, qt.HasLen, 0)
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(contributor), contributor), qt.IsNil)
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(pod), pod), qt.IsNil)
+}
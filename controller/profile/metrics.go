@@ -0,0 +1,169 @@
+package profile
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/johnhoman/kubeflow-profile-manager/apis/v1alpha1"
+)
+
+const (
+	errRegisterCollector = "failed to register profile metrics collector"
+
+	// metricsListTimeout bounds how long a scrape waits on the profile list
+	metricsListTimeout = 10 * time.Second
+)
+
+// Profile states reported by the kubeflow_profiles metric
+const (
+	stateReady       = "ready"
+	stateNotReady    = "not_ready"
+	stateTerminating = "terminating"
+)
+
+var (
+	profilesDesc = prometheus.NewDesc(
+		"kubeflow_profiles",
+		"Number of profiles in each state.",
+		[]string{"state"}, nil,
+	)
+	contributorsDesc = prometheus.NewDesc(
+		"kubeflow_profile_contributors",
+		"Number of contributors in a profile by role.",
+		[]string{"profile", "role"}, nil,
+	)
+	quotaHardDesc = prometheus.NewDesc(
+		"kubeflow_profile_resource_quota_hard",
+		"Hard limit of a resource in a resource quota managed by a profile.",
+		[]string{"profile", "namespace", "resourcequota", "resource"}, nil,
+	)
+	quotaUsedDesc = prometheus.NewDesc(
+		"kubeflow_profile_resource_quota_used",
+		"Usage of a resource in a resource quota managed by a profile.",
+		[]string{"profile", "namespace", "resourcequota", "resource"}, nil,
+	)
+
+	// stepResults counts the result of each ReconcileFunc step
+	stepResults = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kubeflow_profile_reconcile_step_total",
+		Help: "Number of profile reconcile step results by step and result.",
+	}, []string{"step", "result"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(stepResults)
+}
+
+// stepResult returns the result label recorded for a step
+func stepResult(res controllerutil.OperationResult, err error) string {
+	switch {
+	case err != nil:
+		return "error"
+	case res == Stop:
+		return "stop"
+	case res == controllerutil.OperationResultCreated:
+		return "created"
+	case res == controllerutil.OperationResultUpdated,
+		res == controllerutil.OperationResultUpdatedStatus,
+		res == controllerutil.OperationResultUpdatedStatusOnly:
+		return "updated"
+	}
+	return "none"
+}
+
+// observeStep records the result of a step. Skipped steps are disabled or
+// have nothing to manage, so they aren't recorded
+func observeStep(s step, res controllerutil.OperationResult, err error) {
+	if err == nil && res == Skipped {
+		return
+	}
+	stepResults.WithLabelValues(string(s.condition), stepResult(res, err)).Inc()
+}
+
+// RegisterCollector registers a collector that reports the state,
+// contributors and resource quota usage of every profile with the
+// controller-runtime metrics registry
+func RegisterCollector(reader client.Reader) error {
+	err := metrics.Registry.Register(NewCollector(reader))
+	if errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+		return nil
+	}
+	return errors.Wrap(err, errRegisterCollector)
+}
+
+// NewCollector returns a prometheus collector that reads the profiles from
+// reader each time it is collected
+func NewCollector(reader client.Reader) *Collector {
+	return &Collector{reader: reader}
+}
+
+// Collector reports metrics from the observed status of each profile
+type Collector struct {
+	reader client.Reader
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- profilesDesc
+	ch <- contributorsDesc
+	ch <- quotaHardDesc
+	ch <- quotaUsedDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), metricsListTimeout)
+	defer cancel()
+
+	profileList := &v1alpha1.ProfileList{}
+	if err := c.reader.List(ctx, profileList); err != nil {
+		ch <- prometheus.NewInvalidMetric(profilesDesc, err)
+		return
+	}
+
+	states := map[string]int{stateReady: 0, stateNotReady: 0, stateTerminating: 0}
+	for k := range profileList.Items {
+		profile := &profileList.Items[k]
+		states[profileState(profile)]++
+
+		roles := map[string]int{}
+		for _, contributor := range profile.Status.Contributors {
+			roles[contributor.Role]++
+		}
+		for role, count := range roles {
+			ch <- prometheus.MustNewConstMetric(contributorsDesc, prometheus.GaugeValue, float64(count), profile.Name, role)
+		}
+
+		for _, quota := range profile.Status.ResourceQuotas {
+			for name, value := range quota.Hard {
+				ch <- prometheus.MustNewConstMetric(quotaHardDesc, prometheus.GaugeValue, value.AsApproximateFloat64(),
+					profile.Name, quota.Namespace, quota.Name, string(name))
+			}
+			for name, value := range quota.Used {
+				ch <- prometheus.MustNewConstMetric(quotaUsedDesc, prometheus.GaugeValue, value.AsApproximateFloat64(),
+					profile.Name, quota.Namespace, quota.Name, string(name))
+			}
+		}
+	}
+	for state, count := range states {
+		ch <- prometheus.MustNewConstMetric(profilesDesc, prometheus.GaugeValue, float64(count), state)
+	}
+}
+
+// profileState returns the state label of a profile
+func profileState(profile *v1alpha1.Profile) string {
+	switch {
+	case profile.DeletionTimestamp != nil:
+		return stateTerminating
+	case profile.Status.GetCondition(v1alpha1.TypeReady).Status == corev1.ConditionTrue:
+		return stateReady
+	}
+	return stateNotReady
+}
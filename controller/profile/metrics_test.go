@@ -0,0 +1,105 @@
+package profile
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	qt "github.com/frankban/quicktest"
+	"github.com/johnhoman/kubeflow-profile-manager/apis/v1alpha1"
+	"github.com/johnhoman/kubeflow-profile-manager/controller/manager"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCollector(t *testing.T) {
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	now := metav1.Now()
+	k8s := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		&v1alpha1.Profile{
+			ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+			Status: v1alpha1.ProfileStatus{
+				ConditionedStatus: xpv1.ConditionedStatus{Conditions: []xpv1.Condition{
+					{Type: v1alpha1.TypeReady, Status: corev1.ConditionTrue},
+				}},
+				Contributors: []v1alpha1.ProfileContributor{
+					{Name: "starlord", Role: v1alpha1.ContributorRoleOwner},
+					{Name: "gamora", Role: v1alpha1.ContributorRoleContributor},
+					{Name: "drax", Role: v1alpha1.ContributorRoleContributor},
+				},
+				ResourceQuotas: []v1alpha1.ResourceQuotaStatus{{
+					Name:      "kf-resource-quota",
+					Namespace: "starlord",
+					Hard:      corev1.ResourceList{"cpu": resource.MustParse("10")},
+					Used:      corev1.ResourceList{"cpu": resource.MustParse("2500m")},
+				}},
+			},
+		},
+		&v1alpha1.Profile{ObjectMeta: metav1.ObjectMeta{Name: "rocket"}},
+		&v1alpha1.Profile{ObjectMeta: metav1.ObjectMeta{
+			Name:              "groot",
+			DeletionTimestamp: &now,
+			Finalizers:        []string{Finalizer},
+		}},
+	).Build()
+
+	want := `
+# HELP kubeflow_profile_contributors Number of contributors in a profile by role.
+# TYPE kubeflow_profile_contributors gauge
+kubeflow_profile_contributors{profile="starlord",role="Contributor"} 2
+kubeflow_profile_contributors{profile="starlord",role="Owner"} 1
+# HELP kubeflow_profile_resource_quota_hard Hard limit of a resource in a resource quota managed by a profile.
+# TYPE kubeflow_profile_resource_quota_hard gauge
+kubeflow_profile_resource_quota_hard{namespace="starlord",profile="starlord",resource="cpu",resourcequota="kf-resource-quota"} 10
+# HELP kubeflow_profile_resource_quota_used Usage of a resource in a resource quota managed by a profile.
+# TYPE kubeflow_profile_resource_quota_used gauge
+kubeflow_profile_resource_quota_used{namespace="starlord",profile="starlord",resource="cpu",resourcequota="kf-resource-quota"} 2.5
+# HELP kubeflow_profiles Number of profiles in each state.
+# TYPE kubeflow_profiles gauge
+kubeflow_profiles{state="not_ready"} 1
+kubeflow_profiles{state="ready"} 1
+kubeflow_profiles{state="terminating"} 1
+`
+	qt.Assert(t, testutil.CollectAndCompare(NewCollector(k8s), strings.NewReader(want)), qt.IsNil)
+}
+
+func TestReconciler_StepMetrics(t *testing.T) {
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	profile := &v1alpha1.Profile{
+		ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+		Spec: v1alpha1.ProfileSpec{
+			Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+		},
+	}
+	k8s := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(profile).Build()
+
+	created := testutil.ToFloat64(stepResults.WithLabelValues(string(v1alpha1.TypeNamespaceReady), "created"))
+	none := testutil.ToFloat64(stepResults.WithLabelValues(string(v1alpha1.TypeNamespaceReady), "none"))
+	skipped := testutil.ToFloat64(stepResults.WithLabelValues(string(v1alpha1.TypeQuotaReady), "none"))
+
+	r := NewReconciler(manager.FromClient(k8s),
+		WithDefaultNamespaceReconcileFunc(),
+		WithRecorder(event.NewAPIRecorder(record.NewFakeRecorder(100))),
+	)
+	for i := 0; i < 2; i++ {
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(profile)})
+		qt.Assert(t, err, qt.IsNil)
+	}
+
+	qt.Assert(t, testutil.ToFloat64(stepResults.WithLabelValues(string(v1alpha1.TypeNamespaceReady), "created")), qt.Equals, created+1)
+	qt.Assert(t, testutil.ToFloat64(stepResults.WithLabelValues(string(v1alpha1.TypeNamespaceReady), "none")), qt.Equals, none+1)
+	qt.Assert(t, testutil.ToFloat64(stepResults.WithLabelValues(string(v1alpha1.TypeQuotaReady), "none")), qt.Equals, skipped)
+}
@@ -4,19 +4,27 @@ import (
 	"context"
 	"crypto/md5"
 	"fmt"
+	"math"
 	"net/http"
+	"sort"
+	"strings"
 
-	"github.com/crossplane/crossplane-runtime/pkg/controller"
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
 	"github.com/crossplane/crossplane-runtime/pkg/feature"
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/pkg/errors"
+	networkingv1beta1 "istio.io/api/networking/v1beta1"
 	"istio.io/api/security/v1beta1"
+	istionetworking "istio.io/client-go/pkg/apis/networking/v1beta1"
 	istiosecurity "istio.io/client-go/pkg/apis/security/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -25,8 +33,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/johnhoman/kubeflow-profile-manager/apis/v1alpha1"
+	"github.com/johnhoman/kubeflow-profile-manager/controller"
 	"github.com/johnhoman/kubeflow-profile-manager/controller/features"
 	"github.com/johnhoman/kubeflow-profile-manager/controller/manager"
+	"github.com/johnhoman/kubeflow-profile-manager/controller/plugin"
 )
 
 const (
@@ -34,45 +44,150 @@ const (
 	errReconcileAuthorizationPolicy = "failed to reconcile Istio AuthorizationPolicy"
 	errReconcileResourceQuota       = "failed to reconcile resource quota"
 	errReconcileOwnerContributor    = "failed to reconcile owner contributor"
+	errUpdateStatus                 = "failed to update profile status"
+	errAddFinalizer                 = "failed to add profile finalizer"
+	errRemoveFinalizer              = "failed to remove profile finalizer"
+	errIndexProfiles                = "failed to index profiles by namespace"
+	errReconcileContributors        = "failed to reconcile contributors"
+	errRemoveNamespace              = "failed to remove namespace"
+	errReconcileLimitRange          = "failed to reconcile limit range"
+	errReconcileNetworkPolicy       = "failed to reconcile network policy"
+	errReconcileKServe              = "failed to reconcile KServe AuthorizationPolicy"
+	errReconcilePeerAuthentication  = "failed to reconcile Istio PeerAuthentication"
+	errReconcileSidecar             = "failed to reconcile Istio Sidecar"
+	errPruneResourceQuotas          = "failed to prune resource quotas"
+	errObserveResourceQuotas        = "failed to observe resource quotas"
+	errIndexTemplates               = "failed to index profiles by template"
+	errDecodeTemplateObject         = "failed to decode template object"
+
+	errFmtGetTemplate          = "failed to get profile template %q"
+	errFmtApplyTemplateObject  = "failed to apply template object %s %s"
+	errFmtDeleteTemplateObject = "failed to delete template object %s %s"
+
+	errFmtApplyPlugin       = "failed to apply plugin %s"
+	errFmtRevokePlugin      = "failed to revoke plugin %s"
+	errFmtUnsupportedPlugin = "unsupported plugin kind %q"
 
 	errFmtSetControllerRef = "failed to set controller reference on %s"
 
+	msgNamespaceNotOwned = "refusing to update namespace not owned by profile"
+
+	reasonCreated        event.Reason = "Created"
+	reasonUpdated        event.Reason = "Updated"
+	reasonRefused        event.Reason = "Refused"
+	reasonReconcileError event.Reason = "ReconcileError"
+
 	// Stop result is returned from a reconciler when profile reconciliation should stop
 	// and finish gracefully (e.g. without error or requeue)
 	Stop = controllerutil.OperationResult("Stop")
+
+	// Skipped result is returned from a reconciler that is disabled or has nothing
+	// to manage for the profile. The condition for a skipped step is removed from
+	// the profile status
+	Skipped = controllerutil.OperationResult("Skipped")
+
+	// Finalizer is added to every profile so that the profile namespace is
+	// torn down in order before the profile is deleted
+	Finalizer = "profiles.kubeflow.org/finalizer"
+
+	// IndexTargetNamespace is the profile field index of the namespaces
+	// managed by each profile
+	IndexTargetNamespace = "status.namespaces"
+
+	// IndexTemplate is the profile field index of the ProfileTemplate used by
+	// each profile
+	IndexTemplate = "spec.templateRef.name"
+
+	// DefaultQuotaWarningThreshold is the default percentage of a resource
+	// quota a profile can use before the QuotaWarning condition is set
+	DefaultQuotaWarningThreshold = 90
 )
 
+// KServeNamespaceLabels are added to profile namespaces when KServe is
+// enabled so that inference services can be created in them
+var KServeNamespaceLabels = map[string]string{
+	"serving.kubeflow.org/inferenceservice": "enabled",
+	"serving.kserve.io/inferenceservice":    "enabled",
+}
+
+// DefaultSidecarEgressHosts are the hosts every profile Sidecar can reach by
+// default: services in the profile namespace and the Istio and Kubeflow
+// system namespaces
+var DefaultSidecarEgressHosts = []string{
+	"./*",
+	"istio-system/*",
+	"kubeflow/*",
+}
+
+// DefaultNetworkPolicyAllowedNamespaces are the system namespaces allowed
+// ingress into profile namespaces by default
+var DefaultNetworkPolicyAllowedNamespaces = []string{
+	"kubeflow",
+	"istio-system",
+	"knative-serving",
+	"knative-eventing",
+}
+
 // +kubebuilder:rbac:groups=kubeflow.org,resources=profiles,verbs=create;update;delete;patch;get;list;watch
 // +kubebuilder:rbac:groups=kubeflow.org,resources=profiles/status,verbs=patch
 // +kubebuilder:rbac:groups=core,resources=namespaces,verbs=create;update;delete;patch;get;list;watch
 // +kubebuilder:rbac:groups=core,resources=resourcequotas,verbs=get;list;watch;patch;create;update;delete
+// +kubebuilder:rbac:groups=core,resources=limitranges,verbs=get;list;watch;patch;create;update;delete
+// +kubebuilder:rbac:groups=kubeflow.org,resources=profiletemplates,verbs=get;list;watch
 // +kubebuilder:rbac:groups=kubeflow.org,resources=contributors,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;patch;create;update;delete
 // +kubebuilder:rbac:groups=security.istio.io,resources=authorizationpolicies,verbs=create;update;delete;patch;get;list;watch
+// +kubebuilder:rbac:groups=security.istio.io,resources=peerauthentications,verbs=create;update;delete;patch;get;list;watch
+// +kubebuilder:rbac:groups=networking.istio.io,resources=sidecars,verbs=create;update;delete;patch;get;list;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 func Setup(mgr ctrl.Manager, o controller.Options, opts ...ReconcilerOption) error {
 
 	name := "kubeflow.org/profile-manager"
 
+	err := mgr.GetFieldIndexer().IndexField(context.Background(), &v1alpha1.Profile{}, IndexTargetNamespace, func(o client.Object) []string {
+		return managedNamespaces(o.(*v1alpha1.Profile))
+	})
+	if err != nil {
+		return errors.Wrap(err, errIndexProfiles)
+	}
+
 	opts = append(opts,
 		WithLogger(o.Logger.WithValues("controller", "profile-manager")),
 		WithDefaultNamespaceReconcileFunc(),
 		WithDefaultContributorReconcilerFunc(),
+		WithContributorCopiesEnabled(),
 		WithNamespaceAdoptionDisabled(),
 		WithResourceQuotaEnabled(),
+		WithLimitRangeEnabled(),
+		WithTemplateObjectsEnabled(),
+		WithPluginsEnabled(),
+		WithPlugin(plugin.KindWorkloadIdentity, plugin.NewWorkloadIdentity(mgr)),
+		WithPlugin(plugin.KindAzureWorkloadIdentity, plugin.NewAzureWorkloadIdentity(mgr)),
+		WithPlugin(plugin.KindAWSIAMForServiceAccount, plugin.NewAWSIAMForServiceAccount(mgr,
+			plugin.WithOIDCProvider(o.AWSOIDCProvider))),
 	)
+	if o.Recorder != nil {
+		opts = append(opts, WithRecorder(o.Recorder))
+	}
+	if o.Istio != nil {
+		opts = append(opts, WithIstioConfig(*o.Istio))
+	}
 
 	builder := ctrl.NewControllerManagedBy(mgr).
 		Named(name).
 		For(&v1alpha1.Profile{}).
 		Owns(&corev1.Namespace{}).
 		Owns(&corev1.ResourceQuota{}).
+		Owns(&corev1.LimitRange{}).
+		Owns(&corev1.ConfigMap{}).
 		Watches(
 			&source.Kind{Type: &v1alpha1.Contributor{}},
-			handler.EnqueueRequestsFromMapFunc(func(o client.Object) []ctrl.Request {
-				return []ctrl.Request{{
-					NamespacedName: client.ObjectKey{Name: o.GetNamespace()},
-				}}
-			}),
+			handler.EnqueueRequestsFromMapFunc(profilesForNamespace(mgr.GetClient())),
+		).
+		Watches(
+			&source.Kind{Type: &v1alpha1.ProfileTemplate{}},
+			handler.EnqueueRequestsFromMapFunc(profilesForTemplate(mgr.GetClient())),
 		)
 
 	if o.Features.Enabled(features.Istio) {
@@ -80,15 +195,83 @@ func Setup(mgr ctrl.Manager, o controller.Options, opts ...ReconcilerOption) err
 		builder.Owns(&istiosecurity.AuthorizationPolicy{})
 	}
 
+	// PeerAuthentication and Sidecar resources are only needed in the mesh
+	if o.Features.Enabled(features.Istio) && o.Features.Enabled(features.StrictMTLS) {
+		opts = append(opts, WithStrictMTLSEnabled())
+		builder.Owns(&istiosecurity.PeerAuthentication{})
+	}
+
+	if o.Features.Enabled(features.Istio) && o.Features.Enabled(features.SidecarEgress) {
+		opts = append(opts, WithSidecarEgressEnabled())
+		builder.Owns(&istionetworking.Sidecar{})
+	}
+
+	if o.Features.Enabled(features.KServe) {
+		opts = append(opts, WithNamespaceLabels(KServeNamespaceLabels))
+		// the KServe AuthorizationPolicy is only needed in the mesh
+		if o.Features.Enabled(features.Istio) {
+			opts = append(opts, WithKServeEnabled())
+		}
+	}
+
 	if o.Features.Enabled(features.NamespaceAdoption) {
 		opts = append(opts, WithNamespaceAdoptionEnabled())
 	}
 
+	if o.Features.Enabled(features.NetworkPolicy) {
+		opts = append(opts, WithNetworkPolicyEnabled())
+		builder.Owns(&networkingv1.NetworkPolicy{})
+	}
+
 	if o.Features.Enabled(features.Pipelines) {
 		opts = append(opts, WithPipelinesEnabled())
 	}
 
-	return builder.Complete(NewReconciler(mgr, opts...))
+	if err := RegisterCollector(mgr.GetClient()); err != nil {
+		return err
+	}
+
+	r := NewReconciler(mgr, opts...)
+	err = mgr.GetFieldIndexer().IndexField(context.Background(), &v1alpha1.Profile{}, IndexTemplate, func(o client.Object) []string {
+		return []string{r.templateName(o.(*v1alpha1.Profile))}
+	})
+	if err != nil {
+		return errors.Wrap(err, errIndexTemplates)
+	}
+	return builder.Complete(r)
+}
+
+// profilesForTemplate maps a ProfileTemplate to the profiles that use it
+func profilesForTemplate(cli client.Reader) handler.MapFunc {
+	return func(o client.Object) []ctrl.Request {
+		profileList := &v1alpha1.ProfileList{}
+		err := cli.List(context.Background(), profileList, client.MatchingFields{IndexTemplate: o.GetName()})
+		if err != nil {
+			return nil
+		}
+		requests := make([]ctrl.Request, 0, len(profileList.Items))
+		for _, item := range profileList.Items {
+			requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&item)})
+		}
+		return requests
+	}
+}
+
+// profilesForNamespace maps an object to the profiles that manage the
+// namespace of the object
+func profilesForNamespace(cli client.Reader) handler.MapFunc {
+	return func(o client.Object) []ctrl.Request {
+		profileList := &v1alpha1.ProfileList{}
+		err := cli.List(context.Background(), profileList, client.MatchingFields{IndexTargetNamespace: o.GetNamespace()})
+		if err != nil {
+			return nil
+		}
+		requests := make([]ctrl.Request, 0, len(profileList.Items))
+		for _, item := range profileList.Items {
+			requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&item)})
+		}
+		return requests
+	}
 }
 
 type ReconcilerOption func(r *Reconciler)
@@ -111,6 +294,63 @@ func WithIstioEnabled() ReconcilerOption {
 	}
 }
 
+// WithNetworkPolicyEnabled reconciles a NetworkPolicy in each profile
+// namespace that denies ingress from other namespaces
+func WithNetworkPolicyEnabled() ReconcilerOption {
+	return func(r *Reconciler) {
+		r.networkPolicy = r.ReconcileNetworkPolicy
+	}
+}
+
+// WithNetworkPolicyAllowedNamespaces sets the namespaces that are allowed
+// ingress into profile namespaces by the profile NetworkPolicy
+func WithNetworkPolicyAllowedNamespaces(names ...string) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.networkPolicyAllowedNamespaces = names
+	}
+}
+
+// WithKServeEnabled reconciles an AuthorizationPolicy in each profile
+// namespace that allows Knative and the cluster-local gateway to reach
+// inference services
+func WithKServeEnabled() ReconcilerOption {
+	return func(r *Reconciler) {
+		r.kserve = r.ReconcileKServe
+	}
+}
+
+// WithStrictMTLSEnabled reconciles a PeerAuthentication in each profile
+// namespace that only accepts mutual TLS traffic
+func WithStrictMTLSEnabled() ReconcilerOption {
+	return func(r *Reconciler) {
+		r.peerAuthentication = r.ReconcilePeerAuthentication
+	}
+}
+
+// WithSidecarEgressEnabled reconciles an Istio Sidecar in each profile
+// namespace that limits the hosts its workloads can reach
+func WithSidecarEgressEnabled() ReconcilerOption {
+	return func(r *Reconciler) {
+		r.sidecar = r.ReconcileSidecar
+	}
+}
+
+// WithSidecarEgressHosts sets the egress hosts of every profile Sidecar in
+// namespace/dnsName format
+func WithSidecarEgressHosts(hosts ...string) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.sidecarEgressHosts = hosts
+	}
+}
+
+// WithIstioConfig sets the principals and paths used in the profile
+// AuthorizationPolicies
+func WithIstioConfig(config controller.IstioConfig) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.istioConfig = config
+	}
+}
+
 func WithDefaultNamespaceReconcileFunc() ReconcilerOption {
 	return func(r *Reconciler) {
 		r.namespace = r.ReconcileNamespace
@@ -132,9 +372,11 @@ func WithNamespaceLabel(key, value string) ReconcilerOption {
 	return WithNamespaceLabels(map[string]string{key: value})
 }
 
-func WithDefaultResourceQuotaSpec(spec corev1.ResourceQuotaSpec) ReconcilerOption {
+// WithDefaultTemplate sets the ProfileTemplate used by profiles that don't
+// select a template
+func WithDefaultTemplate(name string) ReconcilerOption {
 	return func(r *Reconciler) {
-		r.defaultResourceQuotaSpec = &spec
+		r.defaultTemplate = name
 	}
 }
 
@@ -144,6 +386,37 @@ func WithResourceQuotaEnabled() ReconcilerOption {
 	}
 }
 
+// WithDefaultLimitRangeSpec sets the LimitRange spec used by profiles when
+// neither the profile nor its template specify one
+func WithDefaultLimitRangeSpec(spec corev1.LimitRangeSpec) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.defaultLimitRangeSpec = &spec
+	}
+}
+
+// WithQuotaWarningThreshold sets the percentage of a resource quota a profile
+// can use before the QuotaWarning condition is set
+func WithQuotaWarningThreshold(percent int64) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.quotaWarningThreshold = percent
+	}
+}
+
+// WithLimitRangeEnabled reconciles a LimitRange in each profile namespace
+func WithLimitRangeEnabled() ReconcilerOption {
+	return func(r *Reconciler) {
+		r.limitRange = r.ReconcileLimitRange
+	}
+}
+
+// WithTemplateObjectsEnabled creates the objects in the profile template in
+// each profile namespace
+func WithTemplateObjectsEnabled() ReconcilerOption {
+	return func(r *Reconciler) {
+		r.templateObjects = r.ReconcileTemplateObjects
+	}
+}
+
 func WithPipelinesEnabled() ReconcilerOption {
 	return WithNamespaceLabel("pipelines.kubeflow.org/enabled", "true")
 }
@@ -154,16 +427,45 @@ func WithDefaultContributorReconcilerFunc() ReconcilerOption {
 	}
 }
 
+// WithContributorCopiesEnabled copies the contributors of the profile
+// namespace into each additional profile namespace
+func WithContributorCopiesEnabled() ReconcilerOption {
+	return func(r *Reconciler) {
+		r.contributors = r.ReconcileContributors
+	}
+}
+
+func WithPluginsEnabled() ReconcilerOption {
+	return func(r *Reconciler) {
+		r.plugins = r.ReconcilePlugins
+	}
+}
+
+// WithPlugin registers the implementation for a profile plugin kind
+func WithPlugin(kind string, p plugin.Plugin) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.pluginKinds[kind] = p
+	}
+}
+
 func WithLogger(logger logging.Logger) ReconcilerOption {
 	return func(r *Reconciler) {
 		r.logger = logger
 	}
 }
 
+func WithRecorder(recorder event.Recorder) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.recorder = recorder
+	}
+}
+
 type ReconcileFunc func(ctx context.Context, profile *v1alpha1.Profile) (controllerutil.OperationResult, error)
 
+// NopReconcileFunc is the ReconcileFunc for disabled features. It always
+// returns Skipped
 func NopReconcileFunc(context.Context, *v1alpha1.Profile) (controllerutil.OperationResult, error) {
-	return controllerutil.OperationResultNone, nil
+	return Skipped, nil
 }
 
 // NewReconciler returns a new profile reconciler with all resource reconciliation
@@ -171,14 +473,35 @@ func NopReconcileFunc(context.Context, *v1alpha1.Profile) (controllerutil.Operat
 // Call NewReconciler minimally with NewReconciler(mgr, WithDefaultNamespaceReconcileFunc()).
 func NewReconciler(mgr manager.Manager, opts ...ReconcilerOption) *Reconciler {
 	r := &Reconciler{
-		client: mgr.GetClient(),
-		logger: logging.NewNopLogger(),
+		client:   mgr.GetClient(),
+		reader:   mgr.GetAPIReader(),
+		logger:   logging.NewNopLogger(),
+		recorder: event.NewNopRecorder(),
 
 		// reconcile features
 		namespace:     NopReconcileFunc,
 		istio:         NopReconcileFunc,
 		resourceQuota: NopReconcileFunc,
-		contributor:   NopReconcileFunc,
+		limitRange:    NopReconcileFunc,
+		networkPolicy: NopReconcileFunc,
+		kserve:        NopReconcileFunc,
+
+		peerAuthentication: NopReconcileFunc,
+		sidecar:            NopReconcileFunc,
+		contributor:        NopReconcileFunc,
+		contributors:       NopReconcileFunc,
+		plugins:            NopReconcileFunc,
+
+		templateObjects: NopReconcileFunc,
+
+		pluginKinds: make(map[string]plugin.Plugin),
+
+		quotaWarningThreshold: DefaultQuotaWarningThreshold,
+
+		networkPolicyAllowedNamespaces: DefaultNetworkPolicyAllowedNamespaces,
+
+		istioConfig:        controller.DefaultIstioConfig(),
+		sidecarEgressHosts: DefaultSidecarEgressHosts,
 	}
 	for _, f := range opts {
 		f(r)
@@ -188,20 +511,52 @@ func NewReconciler(mgr manager.Manager, opts ...ReconcilerOption) *Reconciler {
 
 type Reconciler struct {
 	client client.Client
-	logger logging.Logger
+	// reader reads from the API server for objects that aren't worth caching
+	reader   client.Reader
+	logger   logging.Logger
+	recorder event.Recorder
 
 	features *feature.Flags
 
 	namespaceAdoptionEnabled bool
 	namespaceLabels          map[string]string
 
-	defaultResourceQuotaSpec *corev1.ResourceQuotaSpec
+	// defaultTemplate is the ProfileTemplate used by profiles that don't
+	// select a template
+	defaultTemplate string
+
+	defaultLimitRangeSpec *corev1.LimitRangeSpec
+
+	// quotaWarningThreshold is the percentage of a resource quota that sets
+	// the QuotaWarning condition
+	quotaWarningThreshold int64
+
+	// networkPolicyAllowedNamespaces are allowed ingress into profile
+	// namespaces by the profile NetworkPolicy
+	networkPolicyAllowedNamespaces []string
+
+	istioConfig controller.IstioConfig
+
+	// sidecarEgressHosts are the hosts every profile Sidecar can reach
+	sidecarEgressHosts []string
+
+	pluginKinds map[string]plugin.Plugin
 
 	// Features
 	namespace     ReconcileFunc
 	resourceQuota ReconcileFunc
+	limitRange    ReconcileFunc
+	networkPolicy ReconcileFunc
 	istio         ReconcileFunc
-	contributor   ReconcileFunc
+	kserve        ReconcileFunc
+
+	peerAuthentication ReconcileFunc
+	sidecar            ReconcileFunc
+	contributor        ReconcileFunc
+	contributors       ReconcileFunc
+	plugins            ReconcileFunc
+
+	templateObjects ReconcileFunc
 }
 
 func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -210,51 +565,276 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	if err := r.client.Get(ctx, req.NamespacedName, profile); err != nil {
 		return ctrl.Result{}, errors.Wrap(client.IgnoreNotFound(err), "failed to read profile")
 	}
+	if profile.DeletionTimestamp != nil {
+		return r.finalize(ctx, profile)
+	}
+	if !controllerutil.ContainsFinalizer(profile, Finalizer) {
+		updated := profile.DeepCopy()
+		controllerutil.AddFinalizer(updated, Finalizer)
+		if err := r.client.Patch(ctx, updated, client.MergeFrom(profile)); err != nil {
+			return ctrl.Result{}, errors.Wrap(err, errAddFinalizer)
+		}
+		profile = updated
+	}
+
+	patch := client.MergeFrom(profile.DeepCopy())
+	profile.Status.Namespace = profile.TargetNamespace()
 
 	contributorList := &v1alpha1.ContributorList{}
-	if err := r.client.List(ctx, contributorList, client.InNamespace(profile.Name)); err != nil {
+	if err := r.client.List(ctx, contributorList, client.InNamespace(profile.Status.Namespace)); err != nil {
 		return ctrl.Result{}, err
 	}
-	contributors := sets.NewString()
-	for _, item := range contributorList.Items {
-		contributors.Insert(item.Name)
+	sort.Slice(contributorList.Items, func(i, j int) bool {
+		return contributorList.Items[i].Name < contributorList.Items[j].Name
+	})
+	profile.Status.Contributors = make([]v1alpha1.ProfileContributor, len(contributorList.Items))
+	for k, item := range contributorList.Items {
+		profile.Status.Contributors[k] = v1alpha1.ProfileContributor{
+			Name:  item.Name,
+			User:  item.Spec.Name,
+			Role:  item.Spec.Role,
+			Ready: item.Status.GetCondition(v1alpha1.TypeReady).Status,
+		}
 	}
-	patch := client.MergeFrom(profile.DeepCopy())
-	profile.Status.Contributors = make([]corev1.LocalObjectReference, contributors.Len())
-	for k, name := range contributors.List() {
-		profile.Status.Contributors[k].Name = name
+
+	steps := []step{
+		{condition: v1alpha1.TypeNamespaceReady, resource: "namespace", reconcile: r.namespace, stopped: msgNamespaceNotOwned},
+		{condition: v1alpha1.TypeOwnerContributorReady, resource: "owner contributor", reconcile: r.contributor},
+		{condition: v1alpha1.TypeContributorsReady, resource: "contributors", reconcile: r.contributors},
+		{condition: v1alpha1.TypeQuotaReady, resource: "resource quota", reconcile: r.resourceQuota},
+		{condition: v1alpha1.TypeLimitRangeReady, resource: "limit range", reconcile: r.limitRange},
+		{condition: v1alpha1.TypeNetworkPolicyReady, resource: "network policy", reconcile: r.networkPolicy},
+		{condition: v1alpha1.TypeAuthorizationPolicyReady, resource: "authorization policy", reconcile: r.istio},
+		{condition: v1alpha1.TypeKServeReady, resource: "KServe authorization policy", reconcile: r.kserve},
+		{condition: v1alpha1.TypePeerAuthenticationReady, resource: "peer authentication", reconcile: r.peerAuthentication},
+		{condition: v1alpha1.TypeSidecarReady, resource: "sidecar", reconcile: r.sidecar},
+		{condition: v1alpha1.TypeTemplateObjectsReady, resource: "template objects", reconcile: r.templateObjects},
+		{condition: v1alpha1.TypePluginsReady, resource: "plugins", reconcile: r.plugins},
+	}
+
+	var reconcileErr error
+	for k, s := range steps {
+		res, err := s.reconcile(ctx, profile)
+		observeStep(s, res, err)
+		if e, ok := s.eventFor(res, err); ok {
+			r.recorder.Event(profile, e)
+		}
+		if err != nil {
+			reconcileErr = err
+			profile.Status.SetConditions(s.conditionFor(res, err))
+			setPending(profile, s.condition, steps[k+1:])
+			break
+		}
+		if res == Skipped {
+			profile.Status.RemoveConditions(s.condition)
+			continue
+		}
+		profile.Status.SetConditions(s.conditionFor(res, nil))
+		if res == Stop {
+			r.logger.Debug("stop signal received from reconcile func")
+			setPending(profile, s.condition, steps[k+1:])
+			break
+		}
 	}
+
+	profile.Status.SetConditions(synced(reconcileErr), ready(profile, steps))
+	profile.Status.ObservedGeneration = profile.Generation
 	if err := r.client.Status().Patch(ctx, profile, patch); err != nil {
-		return ctrl.Result{}, err
+		return ctrl.Result{}, errors.Wrap(err, errUpdateStatus)
 	}
+	if reconcileErr == nil && len(removedNamespaces(profile)) > 0 && !deletionProtected(profile) {
+		// removed namespaces are waiting for their workloads to terminate
+		return ctrl.Result{RequeueAfter: teardownPollInterval}, nil
+	}
+	return ctrl.Result{}, reconcileErr
+}
 
-	funcs := []ReconcileFunc{
-		r.namespace,
-		r.contributor,
-		r.resourceQuota,
-		r.istio,
+// step is a single ReconcileFunc along with the condition it reports
+// on the profile status
+type step struct {
+	condition xpv1.ConditionType
+	// resource is a human readable name of the resource managed by the step
+	resource  string
+	reconcile ReconcileFunc
+	// stopped is the condition message used when the step returns Stop
+	stopped string
+}
+
+// conditionFor returns the condition for a step given the result of its
+// ReconcileFunc
+func (s step) conditionFor(res controllerutil.OperationResult, err error) xpv1.Condition {
+	c := xpv1.Condition{
+		Type:               s.condition,
+		Status:             corev1.ConditionTrue,
+		Reason:             v1alpha1.ReasonAvailable,
+		LastTransitionTime: metav1.Now(),
+	}
+	switch {
+	case err != nil:
+		c.Status = corev1.ConditionFalse
+		c.Reason = v1alpha1.ReasonReconcileError
+		c.Message = err.Error()
+	case res == Stop:
+		c.Status = corev1.ConditionFalse
+		c.Reason = v1alpha1.ReasonStopped
+		c.Message = s.stopped
+		if c.Message == "" {
+			c.Message = "reconciliation was stopped"
+		}
+	case res == controllerutil.OperationResultCreated:
+		c.Reason = v1alpha1.ReasonCreated
+	case res == controllerutil.OperationResultUpdated,
+		res == controllerutil.OperationResultUpdatedStatus,
+		res == controllerutil.OperationResultUpdatedStatusOnly:
+		c.Reason = v1alpha1.ReasonUpdated
 	}
+	return c
+}
 
-	for _, f := range funcs {
-		res, err := f(ctx, profile)
-		if err != nil {
-			return ctrl.Result{}, err
+// eventFor returns the event to record for a step given the result of its
+// ReconcileFunc. No event is recorded when nothing changed
+func (s step) eventFor(res controllerutil.OperationResult, err error) (event.Event, bool) {
+	switch {
+	case err != nil:
+		return event.Warning(reasonReconcileError, err), true
+	case res == Stop:
+		msg := s.stopped
+		if msg == "" {
+			msg = fmt.Sprintf("reconciliation was stopped by %s", s.resource)
 		}
-		switch res {
-		case controllerutil.OperationResultCreated:
-		case controllerutil.OperationResultUpdated:
-		case Stop:
-			r.logger.Debug("stop signal received from reconcile func")
-			return ctrl.Result{}, nil
+		return event.Warning(reasonRefused, errors.New(msg)), true
+	case res == controllerutil.OperationResultCreated:
+		return event.Normal(reasonCreated, fmt.Sprintf("created %s", s.resource)), true
+	case res == controllerutil.OperationResultUpdated:
+		return event.Normal(reasonUpdated, fmt.Sprintf("updated %s", s.resource)), true
+	}
+	return event.Event{}, false
+}
+
+// setPending marks the conditions of all steps that were not run because
+// reconciliation ended early as Unknown. Conditions for steps that were
+// skipped on a previous reconcile are left absent.
+func setPending(profile *v1alpha1.Profile, after xpv1.ConditionType, steps []step) {
+	for _, s := range steps {
+		if !hasCondition(profile, s.condition) {
+			continue
+		}
+		profile.Status.SetConditions(xpv1.Condition{
+			Type:               s.condition,
+			Status:             corev1.ConditionUnknown,
+			Reason:             v1alpha1.ReasonPending,
+			Message:            fmt.Sprintf("waiting for %s", after),
+			LastTransitionTime: metav1.Now(),
+		})
+	}
+}
+
+// synced returns the Synced condition given the error returned from
+// reconciliation, if any
+func synced(err error) xpv1.Condition {
+	if err != nil {
+		return xpv1.ReconcileError(err)
+	}
+	return xpv1.ReconcileSuccess()
+}
+
+// ready returns the aggregate Ready condition. A profile is ready when the
+// condition for every step that was not skipped is True
+func ready(profile *v1alpha1.Profile, steps []step) xpv1.Condition {
+	for _, s := range steps {
+		if !hasCondition(profile, s.condition) {
+			continue
+		}
+		c := profile.Status.GetCondition(s.condition)
+		if c.Status != corev1.ConditionTrue {
+			msg := fmt.Sprintf("%s is %s", c.Type, c.Status)
+			if c.Message != "" {
+				msg = fmt.Sprintf("%s: %s", msg, c.Message)
+			}
+			return xpv1.Unavailable().WithMessage(msg)
+		}
+	}
+	return xpv1.Available()
+}
+
+func hasCondition(profile *v1alpha1.Profile, ct xpv1.ConditionType) bool {
+	for _, c := range profile.Status.Conditions {
+		if c.Type == ct {
+			return true
 		}
 	}
-	return ctrl.Result{}, nil
+	return false
 }
 
+// ReconcileNamespace reconciles every namespace managed by the profile.
+// Namespaces that were removed from the profile are torn down like the
+// namespaces of a deleted profile, or orphaned when the profile deletion policy
+// is Orphan. A removed namespace stays in the profile status until it is gone,
+// and is kept while the profile is deletion protected.
 func (r *Reconciler) ReconcileNamespace(ctx context.Context, profile *v1alpha1.Profile) (controllerutil.OperationResult, error) {
+	template, err := r.template(ctx, profile)
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+
+	targets := profile.TargetNamespaces()
+	names := make([]string, 0, len(targets))
+	res := controllerutil.OperationResultNone
+	for _, target := range targets {
+		nsRes, err := r.reconcileNamespace(ctx, profile, template, target)
+		if err != nil || nsRes == Stop {
+			return nsRes, err
+		}
+		res = mergeResults(res, nsRes)
+		names = append(names, target.Name)
+	}
+
+	removed := removedNamespaces(profile)
+	if len(removed) > 0 && deletionProtected(profile) {
+		r.logger.Debug(msgRemovalProtected, "namespaces", removed)
+		profile.Status.Namespaces = append(names, removed...)
+		return res, nil
+	}
+	for _, name := range removed {
+		done, err := r.removeNamespace(ctx, profile, name)
+		if err != nil {
+			return controllerutil.OperationResultNone, err
+		}
+		if !done {
+			names = append(names, name)
+			continue
+		}
+		r.logger.Debug("removed namespace from profile", "namespace", name)
+		res = mergeResults(res, controllerutil.OperationResultUpdated)
+	}
+	profile.Status.Namespaces = names
+	return res, nil
+}
+
+// removedNamespaces returns the namespaces in the profile status that are no
+// longer managed by the profile
+func removedNamespaces(profile *v1alpha1.Profile) []string {
+	targets := make([]string, 0)
+	for _, target := range profile.TargetNamespaces() {
+		targets = append(targets, target.Name)
+	}
+	removed := make([]string, 0)
+	for _, name := range profile.Status.Namespaces {
+		if !containsString(targets, name) {
+			removed = append(removed, name)
+		}
+	}
+	return removed
+}
+
+func deletionProtected(profile *v1alpha1.Profile) bool {
+	return profile.GetAnnotations()[v1alpha1.AnnotationDeletionProtection] == "true"
+}
+
+func (r *Reconciler) reconcileNamespace(ctx context.Context, profile *v1alpha1.Profile, template *v1alpha1.ProfileTemplateSpec, target v1alpha1.ProfileNamespace) (controllerutil.OperationResult, error) {
 
 	namespace := &corev1.Namespace{}
-	namespace.Name = profile.Name
+	namespace.Name = target.Name
 
 	updateFn := func() error {
 		if err := controllerutil.SetControllerReference(profile, namespace, r.client.Scheme()); err != nil {
@@ -265,11 +845,20 @@ func (r *Reconciler) ReconcileNamespace(ctx context.Context, profile *v1alpha1.P
 				addLabel(namespace, key, value)
 			}
 		}
+		for key, value := range template.Labels {
+			addLabel(namespace, key, value)
+		}
+		for key, value := range target.Labels {
+			addLabel(namespace, key, value)
+		}
+		for key, value := range template.Annotations {
+			addAnnotation(namespace, key, value)
+		}
 		addAnnotation(namespace, "owner", profile.Spec.Owner.Name)
 		return nil
 	}
 
-	if err := r.client.Get(ctx, client.ObjectKeyFromObject(profile), namespace); err != nil {
+	if err := r.client.Get(ctx, client.ObjectKeyFromObject(namespace), namespace); err != nil {
 		if apierrors.IsNotFound(err) {
 			res, err := controllerutil.CreateOrPatch(ctx, r.client, namespace, updateFn)
 			return res, errors.Wrap(err, errReconcileNamespace)
@@ -280,7 +869,7 @@ func (r *Reconciler) ReconcileNamespace(ctx context.Context, profile *v1alpha1.P
 	annotations := namespace.Annotations
 	if !r.namespaceAdoptionEnabled {
 		if owner, ok := annotations["owner"]; !ok || owner != profile.Spec.Owner.Name {
-			r.logger.Debug("refusing to update namespace not owned by profile")
+			r.logger.Debug(msgNamespaceNotOwned, "namespace", namespace.Name)
 			return Stop, nil
 		}
 	}
@@ -288,10 +877,49 @@ func (r *Reconciler) ReconcileNamespace(ctx context.Context, profile *v1alpha1.P
 	return res, errors.Wrap(err, errReconcileNamespace)
 }
 
+// removeNamespace revokes access to a namespace that was removed from the
+// profile, then deletes or orphans the namespace. A namespace not owned by
+// the profile is left alone. It returns true once the namespace is removed.
+func (r *Reconciler) removeNamespace(ctx context.Context, profile *v1alpha1.Profile, name string) (bool, error) {
+	namespace := &corev1.Namespace{}
+	if err := r.client.Get(ctx, client.ObjectKey{Name: name}, namespace); err != nil {
+		return apierrors.IsNotFound(err), errors.Wrap(client.IgnoreNotFound(err), errRemoveNamespace)
+	}
+	if !metav1.IsControlledBy(namespace, profile) {
+		return true, nil
+	}
+	err := r.client.DeleteAllOf(ctx, &v1alpha1.Contributor{}, client.InNamespace(name), client.HasLabels{v1alpha1.LabelSourceNamespace})
+	if err != nil {
+		return false, errors.Wrap(err, errDeleteContributors)
+	}
+	if profile.Spec.DeletionPolicy == v1alpha1.DeletionPolicyOrphan {
+		return true, r.orphan(ctx, profile, namespace)
+	}
+	done, err := r.deleteNamespaces(ctx, []*corev1.Namespace{namespace}, func(_ xpv1.ConditionReason, msg string) {
+		r.logger.Debug(msg, "namespace", name)
+	})
+	return done, errors.Wrap(err, errRemoveNamespace)
+}
+
+// ReconcileIstioAuthorizationPolicy reconciles the control plane
+// AuthorizationPolicy in every namespace managed by the profile
 func (r *Reconciler) ReconcileIstioAuthorizationPolicy(ctx context.Context, profile *v1alpha1.Profile) (controllerutil.OperationResult, error) {
+	res := controllerutil.OperationResultNone
+	for _, target := range profile.TargetNamespaces() {
+		policyRes, err := r.reconcileIstioAuthorizationPolicy(ctx, profile, target)
+		if err != nil {
+			return policyRes, err
+		}
+		res = mergeResults(res, policyRes)
+	}
+	r.logger.Debug("finished reconciling authorization policy")
+	return res, nil
+}
+
+func (r *Reconciler) reconcileIstioAuthorizationPolicy(ctx context.Context, profile *v1alpha1.Profile, target v1alpha1.ProfileNamespace) (controllerutil.OperationResult, error) {
 	policy := &istiosecurity.AuthorizationPolicy{}
 	policy.Name = "control-plane-access"
-	policy.Namespace = profile.Name
+	policy.Namespace = target.Name
 	res, err := controllerutil.CreateOrPatch(ctx, r.client, policy, func() error {
 		if err := controllerutil.SetControllerReference(profile, policy, r.client.Scheme()); err != nil {
 			return errors.Wrapf(err, errFmtSetControllerRef, "AuthorizationPolicy")
@@ -302,10 +930,7 @@ func (r *Reconciler) ReconcileIstioAuthorizationPolicy(ctx context.Context, prof
 			Rules: []*v1beta1.Rule{{
 				To: []*v1beta1.Rule_To{{
 					Operation: &v1beta1.Operation{
-						// Workloads pathes should be accessible for KNative's
-						// `activator` and `controller` probes
-						// See: https://knative.dev/docs/serving/istio-authorization/#allowing-access-from-system-pods-by-paths
-						Paths: []string{"/healthz", "/metrics", "/wait-for-drain"},
+						Paths: r.istioConfig.ProbePaths,
 					},
 				}},
 			}, {
@@ -313,7 +938,7 @@ func (r *Reconciler) ReconcileIstioAuthorizationPolicy(ctx context.Context, prof
 				// access the api/kernels endpoint of the notebook servers.
 				From: []*v1beta1.Rule_From{{
 					Source: &v1beta1.Source{
-						Principals: []string{principalNotebookController},
+						Principals: []string{r.istioConfig.NotebookControllerPrincipal()},
 					},
 				}},
 				To: []*v1beta1.Rule_To{{
@@ -324,20 +949,402 @@ func (r *Reconciler) ReconcileIstioAuthorizationPolicy(ctx context.Context, prof
 				}},
 			}},
 		}
+		policy.Spec.Rules = append(policy.Spec.Rules, istioRules(profile.Spec.Istio)...)
 		return nil
 	})
-	r.logger.Debug("finished reconciling authorization policy")
 	return res, errors.Wrap(err, errReconcileAuthorizationPolicy)
 }
 
-// ReconcileResourceQuota creates a resource quota in the namespace currently being reconciled. If
-// a profile specifies a resource quota spec, that will be the default spec. If the profile quota
-// is not specified but a default exists, the default resource quota spec will be used
+// ReconcileKServe reconciles the KServe AuthorizationPolicy in every
+// namespace managed by the profile
+func (r *Reconciler) ReconcileKServe(ctx context.Context, profile *v1alpha1.Profile) (controllerutil.OperationResult, error) {
+	res := controllerutil.OperationResultNone
+	for _, target := range profile.TargetNamespaces() {
+		policyRes, err := r.reconcileKServe(ctx, profile, target)
+		if err != nil {
+			return policyRes, err
+		}
+		res = mergeResults(res, policyRes)
+	}
+	return res, nil
+}
+
+func (r *Reconciler) reconcileKServe(ctx context.Context, profile *v1alpha1.Profile, target v1alpha1.ProfileNamespace) (controllerutil.OperationResult, error) {
+	policy := &istiosecurity.AuthorizationPolicy{}
+	policy.Name = "kserve-access"
+	policy.Namespace = target.Name
+	res, err := controllerutil.CreateOrPatch(ctx, r.client, policy, func() error {
+		if err := controllerutil.SetControllerReference(profile, policy, r.client.Scheme()); err != nil {
+			return errors.Wrapf(err, errFmtSetControllerRef, "AuthorizationPolicy")
+		}
+		addLabel(policy, "app.kubernetes.io/part-of", "kubeflow-profile")
+		policy.Spec = v1beta1.AuthorizationPolicy{
+			Action: v1beta1.AuthorizationPolicy_ALLOW,
+			Rules: []*v1beta1.Rule{{
+				From: []*v1beta1.Rule_From{{
+					// in-cluster requests to inference services are routed
+					// through the cluster-local gateway
+					Source: &v1beta1.Source{
+						Principals: []string{r.istioConfig.ClusterLocalGatewayPrincipal()},
+					},
+				}, {
+					// the Knative activator proxies requests to services that
+					// are scaled to zero
+					Source: &v1beta1.Source{
+						Namespaces: []string{r.istioConfig.KnativeServingNamespace},
+					},
+				}},
+			}},
+		}
+		return nil
+	})
+	return res, errors.Wrap(err, errReconcileKServe)
+}
+
+// ReconcilePeerAuthentication creates a STRICT mutual TLS PeerAuthentication
+// in every namespace managed by the profile
+func (r *Reconciler) ReconcilePeerAuthentication(ctx context.Context, profile *v1alpha1.Profile) (controllerutil.OperationResult, error) {
+	res := controllerutil.OperationResultNone
+	for _, target := range profile.TargetNamespaces() {
+		peerRes, err := r.reconcilePeerAuthentication(ctx, profile, target)
+		if err != nil {
+			return peerRes, err
+		}
+		res = mergeResults(res, peerRes)
+	}
+	return res, nil
+}
+
+func (r *Reconciler) reconcilePeerAuthentication(ctx context.Context, profile *v1alpha1.Profile, target v1alpha1.ProfileNamespace) (controllerutil.OperationResult, error) {
+	peer := &istiosecurity.PeerAuthentication{}
+	peer.Name = "kf-strict-mtls"
+	peer.Namespace = target.Name
+	res, err := controllerutil.CreateOrPatch(ctx, r.client, peer, func() error {
+		if err := controllerutil.SetControllerReference(profile, peer, r.client.Scheme()); err != nil {
+			return errors.Wrapf(err, errFmtSetControllerRef, "PeerAuthentication")
+		}
+		addLabel(peer, "app.kubernetes.io/part-of", "kubeflow-profile")
+		peer.Spec = v1beta1.PeerAuthentication{
+			Mtls: &v1beta1.PeerAuthentication_MutualTLS{
+				Mode: v1beta1.PeerAuthentication_MutualTLS_STRICT,
+			},
+		}
+		return nil
+	})
+	return res, errors.Wrap(err, errReconcilePeerAuthentication)
+}
+
+// ReconcileSidecar creates an Istio Sidecar in every namespace managed by the
+// profile. The Sidecar limits egress to the configured hosts and the egress
+// hosts in the profile spec
+func (r *Reconciler) ReconcileSidecar(ctx context.Context, profile *v1alpha1.Profile) (controllerutil.OperationResult, error) {
+	hosts := make([]string, 0, len(r.sidecarEgressHosts))
+	hosts = append(hosts, r.sidecarEgressHosts...)
+	if profile.Spec.Istio != nil {
+		for _, host := range profile.Spec.Istio.EgressHosts {
+			if !containsString(hosts, host) {
+				hosts = append(hosts, host)
+			}
+		}
+	}
+
+	res := controllerutil.OperationResultNone
+	for _, target := range profile.TargetNamespaces() {
+		sidecarRes, err := r.reconcileSidecar(ctx, profile, target, hosts)
+		if err != nil {
+			return sidecarRes, err
+		}
+		res = mergeResults(res, sidecarRes)
+	}
+	return res, nil
+}
+
+func (r *Reconciler) reconcileSidecar(ctx context.Context, profile *v1alpha1.Profile, target v1alpha1.ProfileNamespace, hosts []string) (controllerutil.OperationResult, error) {
+	sidecar := &istionetworking.Sidecar{}
+	sidecar.Name = "kf-sidecar"
+	sidecar.Namespace = target.Name
+	res, err := controllerutil.CreateOrPatch(ctx, r.client, sidecar, func() error {
+		if err := controllerutil.SetControllerReference(profile, sidecar, r.client.Scheme()); err != nil {
+			return errors.Wrapf(err, errFmtSetControllerRef, "Sidecar")
+		}
+		addLabel(sidecar, "app.kubernetes.io/part-of", "kubeflow-profile")
+		sidecar.Spec = networkingv1beta1.Sidecar{
+			Egress: []*networkingv1beta1.IstioEgressListener{{
+				Hosts: hosts,
+			}},
+		}
+		return nil
+	})
+	return res, errors.Wrap(err, errReconcileSidecar)
+}
+
+// istioRules returns the AuthorizationPolicy rules for the additional access
+// in the profile spec. Rules without any fields are dropped because they
+// would allow every request
+func istioRules(istio *v1alpha1.ProfileIstio) []*v1beta1.Rule {
+	if istio == nil {
+		return nil
+	}
+	rules := make([]*v1beta1.Rule, 0, len(istio.Rules)+1)
+
+	// sources in separate From entries match either the principals or the
+	// namespaces
+	var from []*v1beta1.Rule_From
+	if len(istio.Principals) > 0 {
+		from = append(from, &v1beta1.Rule_From{Source: &v1beta1.Source{Principals: istio.Principals}})
+	}
+	if len(istio.Namespaces) > 0 {
+		from = append(from, &v1beta1.Rule_From{Source: &v1beta1.Source{Namespaces: istio.Namespaces}})
+	}
+	if len(from) > 0 {
+		rules = append(rules, &v1beta1.Rule{From: from})
+	}
+	for _, item := range istio.Rules {
+		rule := &v1beta1.Rule{}
+		if len(item.Principals) > 0 || len(item.Namespaces) > 0 {
+			rule.From = []*v1beta1.Rule_From{{
+				Source: &v1beta1.Source{
+					Principals: item.Principals,
+					Namespaces: item.Namespaces,
+				},
+			}}
+		}
+		if len(item.Paths) > 0 || len(item.Methods) > 0 {
+			rule.To = []*v1beta1.Rule_To{{
+				Operation: &v1beta1.Operation{
+					Paths:   item.Paths,
+					Methods: item.Methods,
+				},
+			}}
+		}
+		if rule.From == nil && rule.To == nil {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// ReconcileNetworkPolicy creates a NetworkPolicy in each namespace managed by
+// the profile that denies ingress from everywhere except the namespace itself
+// and the allowed system namespaces
+func (r *Reconciler) ReconcileNetworkPolicy(ctx context.Context, profile *v1alpha1.Profile) (controllerutil.OperationResult, error) {
+	res := controllerutil.OperationResultNone
+	for _, target := range profile.TargetNamespaces() {
+		policyRes, err := r.reconcileNetworkPolicy(ctx, profile, target)
+		if err != nil {
+			return policyRes, err
+		}
+		res = mergeResults(res, policyRes)
+	}
+	return res, nil
+}
+
+func (r *Reconciler) reconcileNetworkPolicy(ctx context.Context, profile *v1alpha1.Profile, target v1alpha1.ProfileNamespace) (controllerutil.OperationResult, error) {
+	policy := &networkingv1.NetworkPolicy{}
+	policy.Name = "kf-default-deny-ingress"
+	policy.Namespace = target.Name
+
+	res, err := controllerutil.CreateOrUpdate(ctx, r.client, policy, func() error {
+		if err := controllerutil.SetControllerReference(profile, policy, r.client.Scheme()); err != nil {
+			return errors.Wrapf(err, errFmtSetControllerRef, "NetworkPolicy")
+		}
+		addLabel(policy, "app.kubernetes.io/part-of", "kubeflow-profile")
+
+		// pods in the profile namespace can always reach each other
+		peers := []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}}
+		if len(r.networkPolicyAllowedNamespaces) > 0 {
+			peers = append(peers, networkingv1.NetworkPolicyPeer{
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{{
+						Key:      corev1.LabelMetadataName,
+						Operator: metav1.LabelSelectorOpIn,
+						Values:   r.networkPolicyAllowedNamespaces,
+					}},
+				},
+			})
+		}
+		policy.Spec = networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress:     []networkingv1.NetworkPolicyIngressRule{{From: peers}},
+		}
+		return nil
+	})
+	return res, errors.Wrap(err, errReconcileNetworkPolicy)
+}
+
+// ReconcileResourceQuota creates a resource quota in each namespace managed by the profile. A
+// namespace quota spec takes precedence over the profile quota spec. If neither is specified but
+// the profile template has one, the template resource quota spec will be used. The named resource
+// quotas in the profile are created in each namespace as well, and quotas managed by the profile
+// that are no longer listed are deleted. The usage of each quota is copied into the profile status
 func (r *Reconciler) ReconcileResourceQuota(ctx context.Context, profile *v1alpha1.Profile) (controllerutil.OperationResult, error) {
+	template, err := r.template(ctx, profile)
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+
+	res := controllerutil.OperationResultNone
+	for _, target := range profile.TargetNamespaces() {
+		quotaRes, err := r.reconcileResourceQuota(ctx, profile, template, target)
+		if err != nil {
+			return quotaRes, err
+		}
+		res = mergeResults(res, quotaRes)
+
+		names := []string{"kf-resource-quota"}
+		for _, item := range profile.Spec.ResourceQuotas {
+			quotaRes, err := r.reconcileNamedResourceQuota(ctx, profile, target, item)
+			if err != nil {
+				return quotaRes, err
+			}
+			res = mergeResults(res, quotaRes)
+			names = append(names, item.Name)
+		}
+
+		pruned, err := r.pruneResourceQuotas(ctx, profile, target, names)
+		if err != nil {
+			return controllerutil.OperationResultNone, err
+		}
+		if pruned {
+			res = mergeResults(res, controllerutil.OperationResultUpdated)
+		}
+	}
+	if err := r.observeResourceQuotas(ctx, profile); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	return res, nil
+}
+
+// observeResourceQuotas copies the hard limits and usage of each resource
+// quota managed by the profile into the profile status, and sets the
+// QuotaWarning condition when any resource is used above the warning threshold.
+// The condition is removed once usage drops back below the threshold
+func (r *Reconciler) observeResourceQuotas(ctx context.Context, profile *v1alpha1.Profile) error {
+	var statuses []v1alpha1.ResourceQuotaStatus
+	var exceeded []string
+	for _, target := range profile.TargetNamespaces() {
+		quotaList := &corev1.ResourceQuotaList{}
+		err := r.client.List(ctx, quotaList,
+			client.InNamespace(target.Name),
+			client.MatchingLabels{"app.kubernetes.io/part-of": "kubeflow-profile"},
+		)
+		if err != nil {
+			return errors.Wrap(err, errObserveResourceQuotas)
+		}
+		sort.Slice(quotaList.Items, func(i, j int) bool {
+			return quotaList.Items[i].Name < quotaList.Items[j].Name
+		})
+		for _, quota := range quotaList.Items {
+			if !metav1.IsControlledBy(&quota, profile) {
+				continue
+			}
+			status := v1alpha1.ResourceQuotaStatus{
+				Name:        quota.Name,
+				Namespace:   quota.Namespace,
+				Hard:        quota.Status.Hard,
+				Used:        quota.Status.Used,
+				Utilization: utilization(quota.Status.Hard, quota.Status.Used),
+			}
+			names := make([]string, 0, len(status.Utilization))
+			for name := range status.Utilization {
+				names = append(names, string(name))
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				percent := status.Utilization[corev1.ResourceName(name)]
+				if percent > r.quotaWarningThreshold {
+					exceeded = append(exceeded, fmt.Sprintf("%s/%s %s is at %d%%", quota.Namespace, quota.Name, name, percent))
+				}
+			}
+			statuses = append(statuses, status)
+		}
+	}
+	profile.Status.ResourceQuotas = statuses
+	if len(exceeded) == 0 {
+		profile.Status.RemoveConditions(v1alpha1.TypeQuotaWarning)
+		return nil
+	}
+	profile.Status.SetConditions(xpv1.Condition{
+		Type:               v1alpha1.TypeQuotaWarning,
+		Status:             corev1.ConditionTrue,
+		Reason:             v1alpha1.ReasonThresholdExceeded,
+		Message:            fmt.Sprintf("resource quota usage is above %d%%: %s", r.quotaWarningThreshold, strings.Join(exceeded, ", ")),
+		LastTransitionTime: metav1.Now(),
+	})
+	return nil
+}
+
+// utilization returns the percentage of each hard limit that is used.
+// Resources without a hard limit are left out
+func utilization(hard, used corev1.ResourceList) map[corev1.ResourceName]int64 {
+	if len(hard) == 0 {
+		return nil
+	}
+	percent := make(map[corev1.ResourceName]int64, len(hard))
+	for name, limit := range hard {
+		usage := used[name]
+		if limit.IsZero() {
+			if usage.IsZero() {
+				percent[name] = 0
+			} else {
+				percent[name] = 100
+			}
+			continue
+		}
+		percent[name] = int64(math.Round(usage.AsApproximateFloat64() / limit.AsApproximateFloat64() * 100))
+	}
+	return percent
+}
+
+func (r *Reconciler) reconcileNamedResourceQuota(ctx context.Context, profile *v1alpha1.Profile, target v1alpha1.ProfileNamespace, item v1alpha1.ProfileResourceQuota) (controllerutil.OperationResult, error) {
+	quota := &corev1.ResourceQuota{}
+	quota.Name = item.Name
+	quota.Namespace = target.Name
+
+	res, err := controllerutil.CreateOrUpdate(ctx, r.client, quota, func() error {
+		if err := controllerutil.SetControllerReference(profile, quota, r.client.Scheme()); err != nil {
+			return errors.Wrapf(err, errFmtSetControllerRef, "ResourceQuota")
+		}
+		addLabel(quota, "app.kubernetes.io/part-of", "kubeflow-profile")
+		quota.Spec = item.Spec
+		return nil
+	})
+	return res, errors.Wrap(err, errReconcileResourceQuota)
+}
+
+// pruneResourceQuotas deletes the resource quotas managed by the profile in a
+// namespace that are not in names. It returns true if a quota was deleted
+func (r *Reconciler) pruneResourceQuotas(ctx context.Context, profile *v1alpha1.Profile, target v1alpha1.ProfileNamespace, names []string) (bool, error) {
+	quotaList := &corev1.ResourceQuotaList{}
+	err := r.client.List(ctx, quotaList,
+		client.InNamespace(target.Name),
+		client.MatchingLabels{"app.kubernetes.io/part-of": "kubeflow-profile"},
+	)
+	if err != nil {
+		return false, errors.Wrap(err, errPruneResourceQuotas)
+	}
+	pruned := false
+	for k := range quotaList.Items {
+		quota := &quotaList.Items[k]
+		if containsString(names, quota.Name) || !metav1.IsControlledBy(quota, profile) {
+			continue
+		}
+		if err := r.client.Delete(ctx, quota); client.IgnoreNotFound(err) != nil {
+			return false, errors.Wrap(err, errPruneResourceQuotas)
+		}
+		r.logger.Debug("pruned resource quota", "namespace", quota.Namespace, "name", quota.Name)
+		pruned = true
+	}
+	return pruned, nil
+}
+
+func (r *Reconciler) reconcileResourceQuota(ctx context.Context, profile *v1alpha1.Profile, template *v1alpha1.ProfileTemplateSpec, target v1alpha1.ProfileNamespace) (controllerutil.OperationResult, error) {
 
 	quota := &corev1.ResourceQuota{}
 	quota.Name = "kf-resource-quota"
-	quota.Namespace = profile.Name
+	quota.Namespace = target.Name
 
 	res, err := controllerutil.CreateOrUpdate(ctx, r.client, quota, func() error {
 		if err := controllerutil.SetControllerReference(profile, quota, r.client.Scheme()); err != nil {
@@ -346,10 +1353,12 @@ func (r *Reconciler) ReconcileResourceQuota(ctx context.Context, profile *v1alph
 		addLabel(quota, "app.kubernetes.io/part-of", "kubeflow-profile")
 		spec := corev1.ResourceQuotaSpec{}
 		switch {
+		case target.ResourceQuotaSpec != nil:
+			spec = *target.ResourceQuotaSpec
 		case profile.Spec.ResourceQuotaSpec != nil:
 			spec = *profile.Spec.ResourceQuotaSpec
-		case r.defaultResourceQuotaSpec != nil:
-			spec = *r.defaultResourceQuotaSpec
+		case template.ResourceQuotaSpec != nil:
+			spec = *template.ResourceQuotaSpec
 		}
 		quota.Spec = spec
 		return nil
@@ -357,6 +1366,62 @@ func (r *Reconciler) ReconcileResourceQuota(ctx context.Context, profile *v1alph
 	return res, errors.Wrap(err, errReconcileResourceQuota)
 }
 
+// ReconcileLimitRange creates a LimitRange in each namespace managed by the
+// profile. The profile limit range takes precedence over the profile template,
+// and the template takes precedence over the default limit range. The
+// LimitRange is deleted when none of them specify one.
+func (r *Reconciler) ReconcileLimitRange(ctx context.Context, profile *v1alpha1.Profile) (controllerutil.OperationResult, error) {
+	template, err := r.template(ctx, profile)
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	spec := r.defaultLimitRangeSpec
+	switch {
+	case profile.Spec.LimitRange != nil:
+		spec = profile.Spec.LimitRange
+	case template.LimitRangeSpec != nil:
+		spec = template.LimitRangeSpec
+	}
+
+	res := controllerutil.OperationResultNone
+	for _, target := range profile.TargetNamespaces() {
+		limitRange := &corev1.LimitRange{}
+		limitRange.Name = "kf-limit-range"
+		limitRange.Namespace = target.Name
+
+		if spec == nil {
+			err := r.client.Get(ctx, client.ObjectKeyFromObject(limitRange), limitRange)
+			if client.IgnoreNotFound(err) != nil {
+				return controllerutil.OperationResultNone, errors.Wrap(err, errReconcileLimitRange)
+			}
+			if err != nil || !metav1.IsControlledBy(limitRange, profile) {
+				continue
+			}
+			if err := r.client.Delete(ctx, limitRange); client.IgnoreNotFound(err) != nil {
+				return controllerutil.OperationResultNone, errors.Wrap(err, errReconcileLimitRange)
+			}
+			continue
+		}
+
+		limitRes, err := controllerutil.CreateOrUpdate(ctx, r.client, limitRange, func() error {
+			if err := controllerutil.SetControllerReference(profile, limitRange, r.client.Scheme()); err != nil {
+				return errors.Wrapf(err, errFmtSetControllerRef, "LimitRange")
+			}
+			addLabel(limitRange, "app.kubernetes.io/part-of", "kubeflow-profile")
+			limitRange.Spec = *spec
+			return nil
+		})
+		if err != nil {
+			return limitRes, errors.Wrap(err, errReconcileLimitRange)
+		}
+		res = mergeResults(res, limitRes)
+	}
+	if spec == nil {
+		return Skipped, nil
+	}
+	return res, nil
+}
+
 func (r *Reconciler) ReconcileContributor(ctx context.Context, profile *v1alpha1.Profile) (controllerutil.OperationResult, error) {
 
 	r.logger.Debug("reconciling owner")
@@ -367,7 +1432,7 @@ func (r *Reconciler) ReconcileContributor(ctx context.Context, profile *v1alpha1
 
 	contrib := &v1alpha1.Contributor{}
 	contrib.Name = profile.Name
-	contrib.Namespace = profile.Name
+	contrib.Namespace = profile.TargetNamespace()
 	res, err := controllerutil.CreateOrPatch(ctx, r.client, contrib, func() error {
 		if err := controllerutil.SetControllerReference(profile, contrib, r.client.Scheme()); err != nil {
 			return errors.Wrapf(err, errFmtSetControllerRef, "Contributor")
@@ -382,8 +1447,293 @@ func (r *Reconciler) ReconcileContributor(ctx context.Context, profile *v1alpha1
 	return res, errors.Wrap(err, errReconcileOwnerContributor)
 }
 
+// ReconcileContributors copies the contributors of the profile namespace into
+// each additional profile namespace, so that every namespace shares the same
+// contributors. Copies of contributors that were removed are deleted.
+func (r *Reconciler) ReconcileContributors(ctx context.Context, profile *v1alpha1.Profile) (controllerutil.OperationResult, error) {
+	targets := profile.TargetNamespaces()
+	if len(targets) == 1 {
+		return Skipped, nil
+	}
+
+	source := profile.TargetNamespace()
+	contributorList := &v1alpha1.ContributorList{}
+	if err := r.client.List(ctx, contributorList, client.InNamespace(source)); err != nil {
+		return controllerutil.OperationResultNone, errors.Wrap(err, errReconcileContributors)
+	}
+
+	res := controllerutil.OperationResultNone
+	for _, target := range targets[1:] {
+		for k := range contributorList.Items {
+			copyRes, err := r.copyContributor(ctx, profile, &contributorList.Items[k], target.Name)
+			if err != nil {
+				return copyRes, err
+			}
+			res = mergeResults(res, copyRes)
+		}
+
+		copies := &v1alpha1.ContributorList{}
+		err := r.client.List(ctx, copies, client.InNamespace(target.Name), client.MatchingLabels{v1alpha1.LabelSourceNamespace: source})
+		if err != nil {
+			return controllerutil.OperationResultNone, errors.Wrap(err, errReconcileContributors)
+		}
+		for k := range copies.Items {
+			if hasContributor(contributorList, copies.Items[k].Name) {
+				continue
+			}
+			if err := r.client.Delete(ctx, &copies.Items[k]); client.IgnoreNotFound(err) != nil {
+				return controllerutil.OperationResultNone, errors.Wrap(err, errReconcileContributors)
+			}
+			res = mergeResults(res, controllerutil.OperationResultUpdated)
+		}
+	}
+	r.logger.Debug("finished reconciling contributors", "result", res)
+	return res, nil
+}
+
+func (r *Reconciler) copyContributor(ctx context.Context, profile *v1alpha1.Profile, contributor *v1alpha1.Contributor, namespace string) (controllerutil.OperationResult, error) {
+	contrib := &v1alpha1.Contributor{}
+	contrib.Name = contributor.Name
+	contrib.Namespace = namespace
+	res, err := controllerutil.CreateOrPatch(ctx, r.client, contrib, func() error {
+		if err := controllerutil.SetControllerReference(profile, contrib, r.client.Scheme()); err != nil {
+			return errors.Wrapf(err, errFmtSetControllerRef, "Contributor")
+		}
+		for key, value := range contributor.Labels {
+			addLabel(contrib, key, value)
+		}
+		addLabel(contrib, v1alpha1.LabelSourceNamespace, contributor.Namespace)
+		contrib.Spec = contributor.Spec
+		return nil
+	})
+	return res, errors.Wrap(err, errReconcileContributors)
+}
+
+func hasContributor(contributorList *v1alpha1.ContributorList, name string) bool {
+	for _, item := range contributorList.Items {
+		if item.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ReconcileTemplateObjects creates the objects in the profile template in
+// each namespace managed by the profile. Objects that were removed from the
+// template are deleted.
+func (r *Reconciler) ReconcileTemplateObjects(ctx context.Context, profile *v1alpha1.Profile) (controllerutil.OperationResult, error) {
+	template, err := r.template(ctx, profile)
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+
+	res := controllerutil.OperationResultNone
+	refs := make([]corev1.ObjectReference, 0)
+	for _, target := range profile.TargetNamespaces() {
+		for _, raw := range template.Objects {
+			desired := &unstructured.Unstructured{}
+			if err := desired.UnmarshalJSON(raw.Raw); err != nil {
+				return controllerutil.OperationResultNone, errors.Wrap(err, errDecodeTemplateObject)
+			}
+			desired.SetNamespace(target.Name)
+			objRes, err := r.applyTemplateObject(ctx, profile, desired)
+			if err != nil {
+				return objRes, err
+			}
+			res = mergeResults(res, objRes)
+			refs = append(refs, corev1.ObjectReference{
+				APIVersion: desired.GetAPIVersion(),
+				Kind:       desired.GetKind(),
+				Namespace:  desired.GetNamespace(),
+				Name:       desired.GetName(),
+			})
+		}
+	}
+
+	for _, ref := range profile.Status.TemplateObjects {
+		if containsObjectReference(refs, ref) {
+			continue
+		}
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(ref.APIVersion)
+		obj.SetKind(ref.Kind)
+		err := r.client.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, obj)
+		if client.IgnoreNotFound(err) != nil {
+			return controllerutil.OperationResultNone, errors.Wrapf(err, errFmtDeleteTemplateObject, ref.Kind, ref.Name)
+		}
+		if err != nil || !metav1.IsControlledBy(obj, profile) {
+			continue
+		}
+		if err := r.client.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return controllerutil.OperationResultNone, errors.Wrapf(err, errFmtDeleteTemplateObject, ref.Kind, ref.Name)
+		}
+		res = mergeResults(res, controllerutil.OperationResultUpdated)
+	}
+
+	if len(refs) == 0 {
+		profile.Status.TemplateObjects = nil
+		return Skipped, nil
+	}
+	profile.Status.TemplateObjects = refs
+	return res, nil
+}
+
+// applyTemplateObject creates or patches a template object. Every field of the
+// desired object except its metadata and status replaces the existing field.
+func (r *Reconciler) applyTemplateObject(ctx context.Context, profile *v1alpha1.Profile, desired *unstructured.Unstructured) (controllerutil.OperationResult, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(desired.GroupVersionKind())
+	obj.SetNamespace(desired.GetNamespace())
+	obj.SetName(desired.GetName())
+	res, err := controllerutil.CreateOrPatch(ctx, r.client, obj, func() error {
+		if err := controllerutil.SetControllerReference(profile, obj, r.client.Scheme()); err != nil {
+			return errors.Wrapf(err, errFmtSetControllerRef, desired.GetKind())
+		}
+		for key, value := range desired.Object {
+			switch key {
+			case "apiVersion", "kind", "metadata", "status":
+				continue
+			}
+			obj.Object[key] = runtime.DeepCopyJSONValue(value)
+		}
+		for key, value := range desired.GetLabels() {
+			addLabel(obj, key, value)
+		}
+		for key, value := range desired.GetAnnotations() {
+			addAnnotation(obj, key, value)
+		}
+		addLabel(obj, "app.kubernetes.io/part-of", "kubeflow-profile")
+		return nil
+	})
+	return res, errors.Wrapf(err, errFmtApplyTemplateObject, desired.GetKind(), desired.GetName())
+}
+
+func containsObjectReference(refs []corev1.ObjectReference, ref corev1.ObjectReference) bool {
+	for _, item := range refs {
+		if item.APIVersion == ref.APIVersion && item.Kind == ref.Kind && item.Namespace == ref.Namespace && item.Name == ref.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// ReconcilePlugins applies every plugin in the profile spec and records the
+// state of each plugin in the profile status. All plugins are applied even if
+// one fails, and the first error is returned. Plugins recorded in the status
+// that were removed from the spec are revoked first.
+func (r *Reconciler) ReconcilePlugins(ctx context.Context, profile *v1alpha1.Profile) (controllerutil.OperationResult, error) {
+	res := controllerutil.OperationResultNone
+	for _, status := range profile.Status.Plugins {
+		p, ok := r.pluginKinds[status.Kind]
+		if !ok || hasPlugin(profile, status.Kind) {
+			continue
+		}
+		if err := p.Revoke(ctx, profile, nil); err != nil {
+			return controllerutil.OperationResultNone, errors.Wrapf(err, errFmtRevokePlugin, status.Kind)
+		}
+		r.logger.Debug("revoked plugin removed from profile", "kind", status.Kind)
+		res = controllerutil.OperationResultUpdated
+	}
+
+	if len(profile.Spec.Plugins) == 0 {
+		profile.Status.Plugins = nil
+		if res == controllerutil.OperationResultUpdated {
+			// report the revoked plugins before the step is skipped
+			return res, nil
+		}
+		return Skipped, nil
+	}
+
+	var applyErr error
+	profile.Status.Plugins = make([]v1alpha1.PluginStatus, len(profile.Spec.Plugins))
+	for k, spec := range profile.Spec.Plugins {
+		status := v1alpha1.PluginStatus{Kind: spec.Kind, Ready: corev1.ConditionTrue}
+		err := errors.Errorf(errFmtUnsupportedPlugin, spec.Kind)
+		if p, ok := r.pluginKinds[spec.Kind]; ok {
+			err = errors.Wrapf(p.Apply(ctx, profile, spec.Spec), errFmtApplyPlugin, spec.Kind)
+		}
+		if err != nil {
+			status.Ready = corev1.ConditionFalse
+			status.Message = err.Error()
+			if applyErr == nil {
+				applyErr = err
+			}
+		}
+		profile.Status.Plugins[k] = status
+	}
+	r.logger.Debug("finished reconciling plugins", "result", res)
+	return res, applyErr
+}
+
+func hasPlugin(profile *v1alpha1.Profile, kind string) bool {
+	for _, p := range profile.Spec.Plugins {
+		if p.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
 var _ reconcile.Reconciler = &Reconciler{}
 
+// templateName returns the name of the ProfileTemplate used by the profile
+func (r *Reconciler) templateName(profile *v1alpha1.Profile) string {
+	if profile.Spec.TemplateRef != nil {
+		return profile.Spec.TemplateRef.Name
+	}
+	return r.defaultTemplate
+}
+
+// template returns the spec of the ProfileTemplate used by the profile. A
+// profile without a template uses an empty template
+func (r *Reconciler) template(ctx context.Context, profile *v1alpha1.Profile) (*v1alpha1.ProfileTemplateSpec, error) {
+	name := r.templateName(profile)
+	if name == "" {
+		return &v1alpha1.ProfileTemplateSpec{}, nil
+	}
+	template := &v1alpha1.ProfileTemplate{}
+	if err := r.client.Get(ctx, client.ObjectKey{Name: name}, template); err != nil {
+		return nil, errors.Wrapf(err, errFmtGetTemplate, name)
+	}
+	return &template.Spec, nil
+}
+
+// managedNamespaces returns the names of the namespaces managed by the
+// profile, including namespaces recorded in the status that were removed
+// from the spec but not cleaned up yet
+func managedNamespaces(profile *v1alpha1.Profile) []string {
+	names := make([]string, 0, len(profile.Spec.Namespaces)+1)
+	for _, target := range profile.TargetNamespaces() {
+		names = append(names, target.Name)
+	}
+	for _, name := range profile.Status.Namespaces {
+		if !containsString(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// mergeResults returns the most significant of two reconcile results
+func mergeResults(a, b controllerutil.OperationResult) controllerutil.OperationResult {
+	switch {
+	case a == controllerutil.OperationResultCreated || b == controllerutil.OperationResultCreated:
+		return controllerutil.OperationResultCreated
+	case a == controllerutil.OperationResultNone:
+		return b
+	}
+	return a
+}
+
+func containsString(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
 func md5Sum(name string) string {
 	return fmt.Sprintf("%x", md5.Sum([]byte(name)))
 }
@@ -405,7 +1755,3 @@ func addAnnotation(o client.Object, key, value string) {
 	annotations[key] = value
 	o.SetAnnotations(annotations)
 }
-
-const (
-	principalNotebookController = "cluster.local/ns/kubeflow/sa/notebook-controller-service-account"
-)
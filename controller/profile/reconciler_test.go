@@ -3,22 +3,32 @@ package profile
 import (
 	"context"
 	"net/http"
+	"sort"
 	"testing"
 
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	qt "github.com/frankban/quicktest"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/johnhoman/kubeflow-profile-manager/apis/v1alpha1"
+	"github.com/johnhoman/kubeflow-profile-manager/controller"
 	"github.com/johnhoman/kubeflow-profile-manager/controller/manager"
+	"github.com/pkg/errors"
 	"istio.io/api/security/v1beta1"
+	istionetworking "istio.io/client-go/pkg/apis/networking/v1beta1"
 	istiosecurity "istio.io/client-go/pkg/apis/security/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/json"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -237,7 +247,8 @@ func TestReconciler_ReconcileNamespace(t *testing.T) {
 				WithObjects(subtest.initObjs...).
 				Build()
 
-			r := NewReconciler(manager.FromClient(k8s), subtest.opts...)
+			opts := append(subtest.opts, WithRecorder(event.NewAPIRecorder(record.NewFakeRecorder(100))))
+			r := NewReconciler(manager.FromClient(k8s), opts...)
 			res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(subtest.profile)})
 			qt.Assert(t, err, qt.IsNil)
 			qt.Assert(t, res, qt.Equals, ctrl.Result{})
@@ -312,6 +323,172 @@ func TestReconciler_ReconcileIstioAuthorizationPolicy(t *testing.T) {
 				},
 			},
 		},
+		"MergesTheProfileIstioAccess": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "starlord",
+				},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{
+						Kind: "User",
+						Name: "starlord@guardians.net",
+					},
+					Istio: &v1alpha1.ProfileIstio{
+						Principals: []string{"cluster.local/ns/gateways/sa/model-gateway"},
+						Namespaces: []string{"ci"},
+						Rules: []v1alpha1.ProfileIstioRule{{
+							Namespaces: []string{"monitoring"},
+							Paths:      []string{"/metrics"},
+							Methods:    []string{http.MethodGet},
+						}, {}},
+					},
+				},
+			},
+			opts: []ReconcilerOption{WithIstioEnabled()},
+			want: &istiosecurity.AuthorizationPolicy{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "control-plane-access",
+					Namespace: "starlord",
+					Labels: map[string]string{
+						"app.kubernetes.io/part-of": "kubeflow-profile",
+					},
+					OwnerReferences: []metav1.OwnerReference{{
+						Name:               "starlord",
+						Kind:               "Profile",
+						APIVersion:         "kubeflow.org/v1alpha1",
+						Controller:         pointer.Bool(true),
+						BlockOwnerDeletion: pointer.Bool(true),
+					}},
+				},
+				Spec: v1beta1.AuthorizationPolicy{
+					Action: v1beta1.AuthorizationPolicy_ALLOW,
+					Rules: []*v1beta1.Rule{{
+						To: []*v1beta1.Rule_To{{
+							Operation: &v1beta1.Operation{
+								Paths: []string{"/healthz", "/metrics", "/wait-for-drain"},
+							},
+						}},
+					}, {
+						From: []*v1beta1.Rule_From{{
+							Source: &v1beta1.Source{
+								Principals: []string{
+									"cluster.local/ns/kubeflow/sa/notebook-controller-service-account",
+								},
+							},
+						}},
+						To: []*v1beta1.Rule_To{{
+							Operation: &v1beta1.Operation{
+								Methods: []string{http.MethodGet},
+								Paths:   []string{"*/api/kernels"},
+							},
+						}},
+					}, {
+						From: []*v1beta1.Rule_From{{
+							Source: &v1beta1.Source{
+								Principals: []string{"cluster.local/ns/gateways/sa/model-gateway"},
+							},
+						}, {
+							Source: &v1beta1.Source{
+								Namespaces: []string{"ci"},
+							},
+						}},
+					}, {
+						From: []*v1beta1.Rule_From{{
+							Source: &v1beta1.Source{
+								Namespaces: []string{"monitoring"},
+							},
+						}},
+						To: []*v1beta1.Rule_To{{
+							Operation: &v1beta1.Operation{
+								Methods: []string{http.MethodGet},
+								Paths:   []string{"/metrics"},
+							},
+						}},
+					}},
+				},
+			},
+		},
+		"ReappliesTheConfiguredPrincipalsToAnExistingPolicy": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "starlord",
+				},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{
+						Kind: "User",
+						Name: "starlord@guardians.net",
+					},
+				},
+			},
+			opts: []ReconcilerOption{
+				WithIstioEnabled(),
+				WithIstioConfig(controller.IstioConfig{
+					TrustDomain:        "guardians.net",
+					NotebookController: controller.ServiceAccount{Namespace: "kubeflow-system", Name: "notebook-controller"},
+					ProbePaths:         []string{"/healthz"},
+				}),
+			},
+			initObjs: []client.Object{
+				&istiosecurity.AuthorizationPolicy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "control-plane-access",
+						Namespace: "starlord",
+					},
+					Spec: v1beta1.AuthorizationPolicy{
+						Action: v1beta1.AuthorizationPolicy_ALLOW,
+						Rules: []*v1beta1.Rule{{
+							From: []*v1beta1.Rule_From{{
+								Source: &v1beta1.Source{
+									Principals: []string{
+										"cluster.local/ns/kubeflow/sa/notebook-controller-service-account",
+									},
+								},
+							}},
+						}},
+					},
+				},
+			},
+			want: &istiosecurity.AuthorizationPolicy{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "control-plane-access",
+					Namespace: "starlord",
+					Labels: map[string]string{
+						"app.kubernetes.io/part-of": "kubeflow-profile",
+					},
+					OwnerReferences: []metav1.OwnerReference{{
+						Name:               "starlord",
+						Kind:               "Profile",
+						APIVersion:         "kubeflow.org/v1alpha1",
+						Controller:         pointer.Bool(true),
+						BlockOwnerDeletion: pointer.Bool(true),
+					}},
+				},
+				Spec: v1beta1.AuthorizationPolicy{
+					Action: v1beta1.AuthorizationPolicy_ALLOW,
+					Rules: []*v1beta1.Rule{{
+						To: []*v1beta1.Rule_To{{
+							Operation: &v1beta1.Operation{
+								Paths: []string{"/healthz"},
+							},
+						}},
+					}, {
+						From: []*v1beta1.Rule_From{{
+							Source: &v1beta1.Source{
+								Principals: []string{
+									"guardians.net/ns/kubeflow-system/sa/notebook-controller",
+								},
+							},
+						}},
+						To: []*v1beta1.Rule_To{{
+							Operation: &v1beta1.Operation{
+								Methods: []string{http.MethodGet},
+								Paths:   []string{"*/api/kernels"},
+							},
+						}},
+					}},
+				},
+			},
+		},
 	}
 	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
 	qt.Assert(t, istiosecurity.AddToScheme(scheme.Scheme), qt.IsNil)
@@ -327,7 +504,10 @@ func TestReconciler_ReconcileIstioAuthorizationPolicy(t *testing.T) {
 				WithObjects(subtest.initObjs...).
 				Build()
 
-			opts := append(subtest.opts, WithLogger(logging.NewLogrLogger(zl)))
+			opts := append(subtest.opts,
+				WithLogger(logging.NewLogrLogger(zl)),
+				WithRecorder(event.NewAPIRecorder(record.NewFakeRecorder(100))),
+			)
 			r := NewReconciler(manager.FromClient(k8s), opts...)
 			res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(subtest.profile)})
 
@@ -399,7 +579,7 @@ func TestReconciler_ReconcileResourceQuota(t *testing.T) {
 				},
 			},
 		},
-		"CreatesAResourceQuantityFromDefault": {
+		"CreatesAResourceQuantityFromTheDefaultTemplate": {
 			profile: &v1alpha1.Profile{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "starlord",
@@ -413,11 +593,19 @@ func TestReconciler_ReconcileResourceQuota(t *testing.T) {
 			},
 			opts: []ReconcilerOption{
 				WithResourceQuotaEnabled(),
-				WithDefaultResourceQuotaSpec(corev1.ResourceQuotaSpec{
-					Hard: corev1.ResourceList{
-						"configmaps": resource.MustParse("10"),
+				WithDefaultTemplate("standard"),
+			},
+			initObjs: []client.Object{
+				&v1alpha1.ProfileTemplate{
+					ObjectMeta: metav1.ObjectMeta{Name: "standard"},
+					Spec: v1alpha1.ProfileTemplateSpec{
+						ResourceQuotaSpec: &corev1.ResourceQuotaSpec{
+							Hard: corev1.ResourceList{
+								"configmaps": resource.MustParse("10"),
+							},
+						},
 					},
-				}),
+				},
 			},
 			want: &corev1.ResourceQuota{
 				ObjectMeta: metav1.ObjectMeta{
@@ -512,7 +700,10 @@ func TestReconciler_ReconcileResourceQuota(t *testing.T) {
 				WithObjects(subtest.initObjs...).
 				Build()
 
-			opts := append(subtest.opts, WithLogger(logging.NewLogrLogger(zl)))
+			opts := append(subtest.opts,
+				WithLogger(logging.NewLogrLogger(zl)),
+				WithRecorder(event.NewAPIRecorder(record.NewFakeRecorder(100))),
+			)
 			r := NewReconciler(manager.FromClient(k8s), opts...)
 			res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(subtest.profile)})
 
@@ -590,7 +781,10 @@ func TestReconciler_ReconcileOwner(t *testing.T) {
 				WithObjects(subtest.initObjs...).
 				Build()
 
-			opts := append(subtest.opts, WithLogger(logging.NewLogrLogger(zl)))
+			opts := append(subtest.opts,
+				WithLogger(logging.NewLogrLogger(zl)),
+				WithRecorder(event.NewAPIRecorder(record.NewFakeRecorder(100))),
+			)
 			r := NewReconciler(manager.FromClient(k8s), opts...)
 			res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(subtest.profile)})
 
@@ -605,3 +799,1222 @@ func TestReconciler_ReconcileOwner(t *testing.T) {
 		})
 	}
 }
+
+func TestReconciler_ReconcileTargetNamespace(t *testing.T) {
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	profile := &v1alpha1.Profile{
+		ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+		Spec: v1alpha1.ProfileSpec{
+			Owner:     rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+			Namespace: "team-ml-prod",
+		},
+	}
+	k8s := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(profile).Build()
+
+	r := NewReconciler(manager.FromClient(k8s),
+		WithDefaultNamespaceReconcileFunc(),
+		WithDefaultContributorReconcilerFunc(),
+		WithResourceQuotaEnabled(),
+		WithRecorder(event.NewAPIRecorder(record.NewFakeRecorder(100))),
+	)
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(profile)})
+	qt.Assert(t, err, qt.IsNil)
+
+	got := &v1alpha1.Profile{}
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(profile), got), qt.IsNil)
+	qt.Assert(t, got.Status.Namespace, qt.Equals, "team-ml-prod")
+	qt.Assert(t, got.Status.GetCondition(v1alpha1.TypeReady).Status, qt.Equals, corev1.ConditionTrue)
+
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKey{Name: "team-ml-prod"}, &corev1.Namespace{}), qt.IsNil)
+	err = k8s.Get(ctx, client.ObjectKey{Name: "starlord"}, &corev1.Namespace{})
+	qt.Assert(t, apierrors.IsNotFound(err), qt.IsTrue)
+
+	key := client.ObjectKey{Namespace: "team-ml-prod", Name: "kf-resource-quota"}
+	qt.Assert(t, k8s.Get(ctx, key, &corev1.ResourceQuota{}), qt.IsNil)
+	key = client.ObjectKey{Namespace: "team-ml-prod", Name: "starlord"}
+	qt.Assert(t, k8s.Get(ctx, key, &v1alpha1.Contributor{}), qt.IsNil)
+}
+
+func TestReconciler_ReconcileNamespaces(t *testing.T) {
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	profile := &v1alpha1.Profile{
+		ObjectMeta: metav1.ObjectMeta{Name: "starlord", UID: "1234"},
+		Spec: v1alpha1.ProfileSpec{
+			Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+			ResourceQuotaSpec: &corev1.ResourceQuotaSpec{
+				Hard: corev1.ResourceList{"configmaps": resource.MustParse("10")},
+			},
+			Namespaces: []v1alpha1.ProfileNamespace{
+				{Name: "starlord-dev", Labels: map[string]string{"env": "dev"}},
+				{Name: "starlord-prod", ResourceQuotaSpec: &corev1.ResourceQuotaSpec{
+					Hard: corev1.ResourceList{"configmaps": resource.MustParse("50")},
+				}},
+			},
+		},
+	}
+	contributor := &v1alpha1.Contributor{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "gamora",
+			Namespace: "starlord",
+			Labels:    map[string]string{"contributor.kubeflow.org/role": "edit"},
+		},
+		Spec: v1alpha1.ContributorSpec{Name: "gamora@guardians.net", Role: v1alpha1.ContributorRoleContributor},
+	}
+	k8s := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(profile, contributor).Build()
+
+	r := NewReconciler(manager.FromClient(k8s),
+		WithDefaultNamespaceReconcileFunc(),
+		WithDefaultContributorReconcilerFunc(),
+		WithContributorCopiesEnabled(),
+		WithResourceQuotaEnabled(),
+		WithRecorder(event.NewAPIRecorder(record.NewFakeRecorder(100))),
+	)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(profile)}
+	_, err := r.Reconcile(ctx, req)
+	qt.Assert(t, err, qt.IsNil)
+
+	got := &v1alpha1.Profile{}
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(profile), got), qt.IsNil)
+	qt.Assert(t, got.Status.Namespaces, qt.DeepEquals, []string{"starlord", "starlord-dev", "starlord-prod"})
+	qt.Assert(t, got.Status.GetCondition(v1alpha1.TypeContributorsReady).Status, qt.Equals, corev1.ConditionTrue)
+
+	namespace := &corev1.Namespace{}
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKey{Name: "starlord-dev"}, namespace), qt.IsNil)
+	qt.Assert(t, namespace.Labels["env"], qt.Equals, "dev")
+	qt.Assert(t, metav1.IsControlledBy(namespace, profile), qt.IsTrue)
+
+	quotas := map[string]string{"starlord": "10", "starlord-dev": "10", "starlord-prod": "50"}
+	for name, want := range quotas {
+		quota := &corev1.ResourceQuota{}
+		qt.Assert(t, k8s.Get(ctx, client.ObjectKey{Namespace: name, Name: "kf-resource-quota"}, quota), qt.IsNil)
+		qt.Assert(t, quota.Spec.Hard["configmaps"], qt.CmpEquals(), resource.MustParse(want))
+	}
+
+	for _, name := range []string{"starlord-dev", "starlord-prod"} {
+		for _, contributor := range []string{"gamora", "starlord"} {
+			copied := &v1alpha1.Contributor{}
+			qt.Assert(t, k8s.Get(ctx, client.ObjectKey{Namespace: name, Name: contributor}, copied), qt.IsNil)
+			qt.Assert(t, copied.Labels[v1alpha1.LabelSourceNamespace], qt.Equals, "starlord")
+		}
+	}
+
+	// removing a contributor removes its copies, and removing a namespace
+	// from the profile deletes it
+	qt.Assert(t, k8s.Delete(ctx, contributor), qt.IsNil)
+	got.Spec.Namespaces = got.Spec.Namespaces[:1]
+	qt.Assert(t, k8s.Update(ctx, got), qt.IsNil)
+	_, err = r.Reconcile(ctx, req)
+	qt.Assert(t, err, qt.IsNil)
+
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(profile), got), qt.IsNil)
+	qt.Assert(t, got.Status.Namespaces, qt.DeepEquals, []string{"starlord", "starlord-dev"})
+	err = k8s.Get(ctx, client.ObjectKey{Namespace: "starlord-dev", Name: "gamora"}, &v1alpha1.Contributor{})
+	qt.Assert(t, apierrors.IsNotFound(err), qt.IsTrue)
+	err = k8s.Get(ctx, client.ObjectKey{Name: "starlord-prod"}, &corev1.Namespace{})
+	qt.Assert(t, apierrors.IsNotFound(err), qt.IsTrue)
+}
+
+func TestReconciler_RemoveNamespace(t *testing.T) {
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	profile := &v1alpha1.Profile{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "starlord",
+			UID:         "1234",
+			Annotations: map[string]string{v1alpha1.AnnotationDeletionProtection: "true"},
+		},
+		Spec: v1alpha1.ProfileSpec{
+			Owner:      rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+			Namespaces: []v1alpha1.ProfileNamespace{{Name: "starlord-dev"}},
+		},
+	}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "notebook-0", Namespace: "starlord-dev"}}
+	k8s := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(profile, pod).Build()
+
+	r := NewReconciler(manager.FromClient(k8s),
+		WithDefaultNamespaceReconcileFunc(),
+		WithRecorder(event.NewAPIRecorder(record.NewFakeRecorder(100))),
+	)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(profile)}
+	_, err := r.Reconcile(ctx, req)
+	qt.Assert(t, err, qt.IsNil)
+
+	// a deletion protected profile keeps the removed namespace
+	got := &v1alpha1.Profile{}
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(profile), got), qt.IsNil)
+	got.Spec.Namespaces = nil
+	qt.Assert(t, k8s.Update(ctx, got), qt.IsNil)
+	res, err := r.Reconcile(ctx, req)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, res, qt.Equals, ctrl.Result{})
+
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(profile), got), qt.IsNil)
+	qt.Assert(t, got.Status.Namespaces, qt.DeepEquals, []string{"starlord", "starlord-dev"})
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKey{Name: "starlord-dev"}, &corev1.Namespace{}), qt.IsNil)
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(pod), &corev1.Pod{}), qt.IsNil)
+
+	// the namespace is torn down once the protection is removed
+	delete(got.Annotations, v1alpha1.AnnotationDeletionProtection)
+	qt.Assert(t, k8s.Update(ctx, got), qt.IsNil)
+	_, err = r.Reconcile(ctx, req)
+	qt.Assert(t, err, qt.IsNil)
+
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(profile), got), qt.IsNil)
+	qt.Assert(t, got.Status.Namespaces, qt.DeepEquals, []string{"starlord"})
+	err = k8s.Get(ctx, client.ObjectKeyFromObject(pod), &corev1.Pod{})
+	qt.Assert(t, apierrors.IsNotFound(err), qt.IsTrue)
+	err = k8s.Get(ctx, client.ObjectKey{Name: "starlord-dev"}, &corev1.Namespace{})
+	qt.Assert(t, apierrors.IsNotFound(err), qt.IsTrue)
+}
+
+func TestReconciler_ReconcileTemplate(t *testing.T) {
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	template := &v1alpha1.ProfileTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "ml-team"},
+		Spec: v1alpha1.ProfileTemplateSpec{
+			Labels:      map[string]string{"team": "ml", "env": "prod"},
+			Annotations: map[string]string{"cost-center": "1234"},
+			ResourceQuotaSpec: &corev1.ResourceQuotaSpec{
+				Hard: corev1.ResourceList{"configmaps": resource.MustParse("10")},
+			},
+			LimitRangeSpec: &corev1.LimitRangeSpec{
+				Limits: []corev1.LimitRangeItem{{
+					Type:    corev1.LimitTypeContainer,
+					Default: corev1.ResourceList{"memory": resource.MustParse("1Gi")},
+				}},
+			},
+			Objects: []runtime.RawExtension{{
+				Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"team-settings"},"data":{"team":"ml"}}`),
+			}},
+		},
+	}
+	profile := &v1alpha1.Profile{
+		ObjectMeta: metav1.ObjectMeta{Name: "starlord", UID: "1234"},
+		Spec: v1alpha1.ProfileSpec{
+			Owner:       rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+			TemplateRef: &corev1.LocalObjectReference{Name: "ml-team"},
+			ResourceQuotaSpec: &corev1.ResourceQuotaSpec{
+				Hard: corev1.ResourceList{"configmaps": resource.MustParse("20")},
+			},
+			Namespaces: []v1alpha1.ProfileNamespace{
+				{Name: "starlord-dev", Labels: map[string]string{"env": "dev"}},
+			},
+		},
+	}
+	k8s := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(template, profile).Build()
+
+	r := NewReconciler(manager.FromClient(k8s),
+		WithDefaultNamespaceReconcileFunc(),
+		WithResourceQuotaEnabled(),
+		WithLimitRangeEnabled(),
+		WithTemplateObjectsEnabled(),
+		WithRecorder(event.NewAPIRecorder(record.NewFakeRecorder(100))),
+	)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(profile)}
+	_, err := r.Reconcile(ctx, req)
+	qt.Assert(t, err, qt.IsNil)
+
+	namespace := &corev1.Namespace{}
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKey{Name: "starlord"}, namespace), qt.IsNil)
+	qt.Assert(t, namespace.Labels, qt.DeepEquals, map[string]string{"team": "ml", "env": "prod"})
+	qt.Assert(t, namespace.Annotations["cost-center"], qt.Equals, "1234")
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKey{Name: "starlord-dev"}, namespace), qt.IsNil)
+	qt.Assert(t, namespace.Labels, qt.DeepEquals, map[string]string{"team": "ml", "env": "dev"})
+
+	for _, name := range []string{"starlord", "starlord-dev"} {
+		quota := &corev1.ResourceQuota{}
+		qt.Assert(t, k8s.Get(ctx, client.ObjectKey{Namespace: name, Name: "kf-resource-quota"}, quota), qt.IsNil)
+		qt.Assert(t, quota.Spec.Hard["configmaps"], qt.CmpEquals(), resource.MustParse("20"))
+
+		limitRange := &corev1.LimitRange{}
+		qt.Assert(t, k8s.Get(ctx, client.ObjectKey{Namespace: name, Name: "kf-limit-range"}, limitRange), qt.IsNil)
+		qt.Assert(t, limitRange.Spec, qt.CmpEquals(), *template.Spec.LimitRangeSpec)
+
+		cm := &corev1.ConfigMap{}
+		qt.Assert(t, k8s.Get(ctx, client.ObjectKey{Namespace: name, Name: "team-settings"}, cm), qt.IsNil)
+		qt.Assert(t, cm.Data, qt.DeepEquals, map[string]string{"team": "ml"})
+		qt.Assert(t, metav1.IsControlledBy(cm, profile), qt.IsTrue)
+	}
+
+	got := &v1alpha1.Profile{}
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(profile), got), qt.IsNil)
+	qt.Assert(t, got.Status.TemplateObjects, qt.HasLen, 2)
+	qt.Assert(t, got.Status.GetCondition(v1alpha1.TypeReady).Status, qt.Equals, corev1.ConditionTrue)
+
+	// objects and the limit range removed from the template are deleted
+	template.Spec.Objects = nil
+	template.Spec.LimitRangeSpec = nil
+	qt.Assert(t, k8s.Update(ctx, template), qt.IsNil)
+	_, err = r.Reconcile(ctx, req)
+	qt.Assert(t, err, qt.IsNil)
+
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(profile), got), qt.IsNil)
+	qt.Assert(t, got.Status.TemplateObjects, qt.HasLen, 0)
+	for _, name := range []string{"starlord", "starlord-dev"} {
+		err := k8s.Get(ctx, client.ObjectKey{Namespace: name, Name: "team-settings"}, &corev1.ConfigMap{})
+		qt.Assert(t, apierrors.IsNotFound(err), qt.IsTrue)
+		err = k8s.Get(ctx, client.ObjectKey{Namespace: name, Name: "kf-limit-range"}, &corev1.LimitRange{})
+		qt.Assert(t, apierrors.IsNotFound(err), qt.IsTrue)
+	}
+}
+
+func TestReconciler_ReconcileLimitRange(t *testing.T) {
+	limits := func(memory string) *corev1.LimitRangeSpec {
+		return &corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{{
+				Type:           corev1.LimitTypeContainer,
+				DefaultRequest: corev1.ResourceList{"memory": resource.MustParse(memory)},
+			}},
+		}
+	}
+	template := &v1alpha1.ProfileTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "ml-team"},
+		Spec:       v1alpha1.ProfileTemplateSpec{LimitRangeSpec: limits("512Mi")},
+	}
+
+	cases := map[string]struct {
+		limitRange  *corev1.LimitRangeSpec
+		templateRef *corev1.LocalObjectReference
+		opts        []ReconcilerOption
+		want        *corev1.LimitRangeSpec
+	}{
+		"CreatesALimitRangeFromTheProfile": {
+			limitRange: limits("256Mi"),
+			want:       limits("256Mi"),
+		},
+		"CreatesALimitRangeFromTheDefault": {
+			opts: []ReconcilerOption{WithDefaultLimitRangeSpec(*limits("1Gi"))},
+			want: limits("1Gi"),
+		},
+		"PrefersTheTemplateOverTheDefault": {
+			templateRef: &corev1.LocalObjectReference{Name: "ml-team"},
+			opts:        []ReconcilerOption{WithDefaultLimitRangeSpec(*limits("1Gi"))},
+			want:        limits("512Mi"),
+		},
+		"PrefersTheProfileOverTheTemplate": {
+			limitRange:  limits("256Mi"),
+			templateRef: &corev1.LocalObjectReference{Name: "ml-team"},
+			want:        limits("256Mi"),
+		},
+		"SkipsAProfileWithoutALimitRange": {},
+	}
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	for name, subtest := range cases {
+		t.Run(name, func(t *testing.T) {
+			profile := &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner:       rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+					LimitRange:  subtest.limitRange,
+					TemplateRef: subtest.templateRef,
+				},
+			}
+			k8s := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(profile, template.DeepCopy()).Build()
+
+			opts := append(subtest.opts,
+				WithLimitRangeEnabled(),
+				WithRecorder(event.NewAPIRecorder(record.NewFakeRecorder(100))),
+			)
+			r := NewReconciler(manager.FromClient(k8s), opts...)
+			_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(profile)})
+			qt.Assert(t, err, qt.IsNil)
+
+			got := &v1alpha1.Profile{}
+			qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(profile), got), qt.IsNil)
+
+			limitRange := &corev1.LimitRange{}
+			err = k8s.Get(ctx, client.ObjectKey{Namespace: "starlord", Name: "kf-limit-range"}, limitRange)
+			if subtest.want == nil {
+				qt.Assert(t, apierrors.IsNotFound(err), qt.IsTrue)
+				qt.Assert(t, hasCondition(got, v1alpha1.TypeLimitRangeReady), qt.IsFalse)
+				return
+			}
+			qt.Assert(t, err, qt.IsNil)
+			qt.Assert(t, limitRange.Spec, qt.CmpEquals(), *subtest.want)
+			qt.Assert(t, got.Status.GetCondition(v1alpha1.TypeLimitRangeReady).Reason, qt.Equals, v1alpha1.ReasonCreated)
+		})
+	}
+}
+
+func TestReconciler_ReconcileNamedResourceQuotas(t *testing.T) {
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	profile := &v1alpha1.Profile{
+		ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+		Spec: v1alpha1.ProfileSpec{
+			Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+			ResourceQuotas: []v1alpha1.ProfileResourceQuota{
+				{
+					Name: "best-effort",
+					Spec: corev1.ResourceQuotaSpec{
+						Hard:   corev1.ResourceList{"pods": resource.MustParse("5")},
+						Scopes: []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeBestEffort},
+					},
+				},
+				{
+					Name: "high-priority",
+					Spec: corev1.ResourceQuotaSpec{
+						Hard: corev1.ResourceList{"pods": resource.MustParse("2")},
+						ScopeSelector: &corev1.ScopeSelector{
+							MatchExpressions: []corev1.ScopedResourceSelectorRequirement{{
+								ScopeName: corev1.ResourceQuotaScopePriorityClass,
+								Operator:  corev1.ScopeSelectorOpIn,
+								Values:    []string{"high"},
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+	unmanaged := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "unmanaged",
+			Namespace: "starlord",
+			Labels:    map[string]string{"app.kubernetes.io/part-of": "kubeflow-profile"},
+		},
+	}
+	k8s := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(profile, unmanaged).Build()
+	r := NewReconciler(manager.FromClient(k8s),
+		WithResourceQuotaEnabled(),
+		WithRecorder(event.NewAPIRecorder(record.NewFakeRecorder(100))),
+	)
+
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(profile)})
+	qt.Assert(t, err, qt.IsNil)
+
+	for _, item := range profile.Spec.ResourceQuotas {
+		quota := &corev1.ResourceQuota{}
+		qt.Assert(t, k8s.Get(ctx, client.ObjectKey{Namespace: "starlord", Name: item.Name}, quota), qt.IsNil)
+		qt.Assert(t, quota.Spec, qt.CmpEquals(), item.Spec)
+		qt.Assert(t, quota.Labels["app.kubernetes.io/part-of"], qt.Equals, "kubeflow-profile")
+	}
+
+	got := &v1alpha1.Profile{}
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(profile), got), qt.IsNil)
+	got.Spec.ResourceQuotas = got.Spec.ResourceQuotas[:1]
+	qt.Assert(t, k8s.Update(ctx, got), qt.IsNil)
+
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(profile)})
+	qt.Assert(t, err, qt.IsNil)
+
+	quotaList := &corev1.ResourceQuotaList{}
+	qt.Assert(t, k8s.List(ctx, quotaList, client.InNamespace("starlord")), qt.IsNil)
+	names := make([]string, 0, len(quotaList.Items))
+	for _, quota := range quotaList.Items {
+		names = append(names, quota.Name)
+	}
+	sort.Strings(names)
+	qt.Assert(t, names, qt.DeepEquals, []string{"best-effort", "kf-resource-quota", "unmanaged"})
+}
+
+func TestReconciler_ReconcileResourceQuotaUsage(t *testing.T) {
+	cases := map[string]struct {
+		opts    []ReconcilerOption
+		status  corev1.ConditionStatus
+		reason  xpv1.ConditionReason
+		message string
+	}{
+		"WarnsAboveTheDefaultThreshold": {
+			status:  corev1.ConditionTrue,
+			reason:  v1alpha1.ReasonThresholdExceeded,
+			message: "resource quota usage is above 90%: starlord/kf-resource-quota cpu is at 95%",
+		},
+		"DoesNotWarnWithinTheThreshold": {
+			opts:   []ReconcilerOption{WithQuotaWarningThreshold(95)},
+			status: corev1.ConditionUnknown,
+		},
+	}
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	for name, subtest := range cases {
+		t.Run(name, func(t *testing.T) {
+			profile := &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord", UID: "starlord-uid"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+					ResourceQuotaSpec: &corev1.ResourceQuotaSpec{
+						Hard: corev1.ResourceList{"cpu": resource.MustParse("10"), "pods": resource.MustParse("10")},
+					},
+				},
+			}
+			quota := &corev1.ResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "kf-resource-quota",
+					Namespace: "starlord",
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion: "kubeflow.org/v1alpha1",
+						Kind:       "Profile",
+						Name:       "starlord",
+						UID:        "starlord-uid",
+						Controller: pointer.Bool(true),
+					}},
+				},
+				Status: corev1.ResourceQuotaStatus{
+					Hard: corev1.ResourceList{"cpu": resource.MustParse("10"), "pods": resource.MustParse("10")},
+					Used: corev1.ResourceList{"cpu": resource.MustParse("9500m"), "pods": resource.MustParse("3")},
+				},
+			}
+			k8s := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(profile, quota).Build()
+
+			opts := append(subtest.opts,
+				WithResourceQuotaEnabled(),
+				WithRecorder(event.NewAPIRecorder(record.NewFakeRecorder(100))),
+			)
+			r := NewReconciler(manager.FromClient(k8s), opts...)
+			_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(profile)})
+			qt.Assert(t, err, qt.IsNil)
+
+			got := &v1alpha1.Profile{}
+			qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(profile), got), qt.IsNil)
+			qt.Assert(t, got.Status.ResourceQuotas, qt.CmpEquals(), []v1alpha1.ResourceQuotaStatus{{
+				Name:        "kf-resource-quota",
+				Namespace:   "starlord",
+				Hard:        quota.Status.Hard,
+				Used:        quota.Status.Used,
+				Utilization: map[corev1.ResourceName]int64{"cpu": 95, "pods": 30},
+			}})
+
+			c := got.Status.GetCondition(v1alpha1.TypeQuotaWarning)
+			qt.Assert(t, c.Status, qt.Equals, subtest.status)
+			qt.Assert(t, c.Reason, qt.Equals, subtest.reason)
+			qt.Assert(t, c.Message, qt.Equals, subtest.message)
+			qt.Assert(t, got.Status.GetCondition(v1alpha1.TypeReady).Status, qt.Equals, corev1.ConditionTrue)
+		})
+	}
+}
+
+func TestReconciler_ReconcileNetworkPolicy(t *testing.T) {
+	allowed := func(names ...string) networkingv1.NetworkPolicyPeer {
+		return networkingv1.NetworkPolicyPeer{
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key:      "kubernetes.io/metadata.name",
+					Operator: metav1.LabelSelectorOpIn,
+					Values:   names,
+				}},
+			},
+		}
+	}
+	sameNamespace := networkingv1.NetworkPolicyPeer{PodSelector: &metav1.LabelSelector{}}
+
+	cases := map[string]struct {
+		opts []ReconcilerOption
+		want []networkingv1.NetworkPolicyPeer
+	}{
+		"AllowsTheDefaultSystemNamespaces": {
+			want: []networkingv1.NetworkPolicyPeer{
+				sameNamespace,
+				allowed("kubeflow", "istio-system", "knative-serving", "knative-eventing"),
+			},
+		},
+		"AllowsTheConfiguredNamespaces": {
+			opts: []ReconcilerOption{WithNetworkPolicyAllowedNamespaces("kubeflow", "monitoring")},
+			want: []networkingv1.NetworkPolicyPeer{sameNamespace, allowed("kubeflow", "monitoring")},
+		},
+		"AllowsOnlyTheSameNamespace": {
+			opts: []ReconcilerOption{WithNetworkPolicyAllowedNamespaces()},
+			want: []networkingv1.NetworkPolicyPeer{sameNamespace},
+		},
+	}
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	for name, subtest := range cases {
+		t.Run(name, func(t *testing.T) {
+			profile := &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner:      rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+					Namespaces: []v1alpha1.ProfileNamespace{{Name: "starlord-dev"}},
+				},
+			}
+			k8s := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(profile).Build()
+
+			opts := append(subtest.opts,
+				WithNetworkPolicyEnabled(),
+				WithRecorder(event.NewAPIRecorder(record.NewFakeRecorder(100))),
+			)
+			r := NewReconciler(manager.FromClient(k8s), opts...)
+			_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(profile)})
+			qt.Assert(t, err, qt.IsNil)
+
+			for _, namespace := range []string{"starlord", "starlord-dev"} {
+				policy := &networkingv1.NetworkPolicy{}
+				qt.Assert(t, k8s.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "kf-default-deny-ingress"}, policy), qt.IsNil)
+				qt.Assert(t, policy.Spec, qt.CmpEquals(), networkingv1.NetworkPolicySpec{
+					PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+					Ingress:     []networkingv1.NetworkPolicyIngressRule{{From: subtest.want}},
+				})
+				qt.Assert(t, policy.Labels["app.kubernetes.io/part-of"], qt.Equals, "kubeflow-profile")
+			}
+
+			got := &v1alpha1.Profile{}
+			qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(profile), got), qt.IsNil)
+			qt.Assert(t, got.Status.GetCondition(v1alpha1.TypeNetworkPolicyReady).Reason, qt.Equals, v1alpha1.ReasonCreated)
+		})
+	}
+}
+
+func TestReconciler_ReconcileKServe(t *testing.T) {
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+	qt.Assert(t, istiosecurity.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	profile := &v1alpha1.Profile{
+		ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+		Spec: v1alpha1.ProfileSpec{
+			Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+		},
+	}
+	k8s := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(profile).Build()
+
+	r := NewReconciler(manager.FromClient(k8s),
+		WithDefaultNamespaceReconcileFunc(),
+		WithNamespaceLabels(KServeNamespaceLabels),
+		WithKServeEnabled(),
+		WithRecorder(event.NewAPIRecorder(record.NewFakeRecorder(100))),
+	)
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(profile)})
+	qt.Assert(t, err, qt.IsNil)
+
+	namespace := &corev1.Namespace{}
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKey{Name: "starlord"}, namespace), qt.IsNil)
+	qt.Assert(t, namespace.Labels["serving.kubeflow.org/inferenceservice"], qt.Equals, "enabled")
+	qt.Assert(t, namespace.Labels["serving.kserve.io/inferenceservice"], qt.Equals, "enabled")
+
+	policy := &istiosecurity.AuthorizationPolicy{}
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKey{Namespace: "starlord", Name: "kserve-access"}, policy), qt.IsNil)
+	qt.Assert(t, policy.Spec.Action, qt.Equals, v1beta1.AuthorizationPolicy_ALLOW)
+	qt.Assert(t, policy.Spec.Rules, qt.HasLen, 1)
+	from := policy.Spec.Rules[0].From
+	qt.Assert(t, from, qt.HasLen, 2)
+	qt.Assert(t, from[0].Source.Principals, qt.DeepEquals, []string{"cluster.local/ns/istio-system/sa/cluster-local-gateway-service-account"})
+	qt.Assert(t, from[1].Source.Namespaces, qt.DeepEquals, []string{"knative-serving"})
+
+	got := &v1alpha1.Profile{}
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(profile), got), qt.IsNil)
+	qt.Assert(t, got.Status.GetCondition(v1alpha1.TypeKServeReady).Reason, qt.Equals, v1alpha1.ReasonCreated)
+}
+
+func TestReconciler_ReconcilePeerAuthentication(t *testing.T) {
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+	qt.Assert(t, istiosecurity.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	profile := &v1alpha1.Profile{
+		ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+		Spec: v1alpha1.ProfileSpec{
+			Owner:      rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+			Namespaces: []v1alpha1.ProfileNamespace{{Name: "starlord-dev"}},
+		},
+	}
+	k8s := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(profile).Build()
+
+	r := NewReconciler(manager.FromClient(k8s),
+		WithStrictMTLSEnabled(),
+		WithRecorder(event.NewAPIRecorder(record.NewFakeRecorder(100))),
+	)
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(profile)})
+	qt.Assert(t, err, qt.IsNil)
+
+	for _, namespace := range []string{"starlord", "starlord-dev"} {
+		peer := &istiosecurity.PeerAuthentication{}
+		qt.Assert(t, k8s.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "kf-strict-mtls"}, peer), qt.IsNil)
+		qt.Assert(t, peer.Spec.Mtls.Mode, qt.Equals, v1beta1.PeerAuthentication_MutualTLS_STRICT)
+		qt.Assert(t, peer.Spec.Selector, qt.IsNil)
+		qt.Assert(t, metav1.GetControllerOf(peer).Name, qt.Equals, "starlord")
+	}
+
+	got := &v1alpha1.Profile{}
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(profile), got), qt.IsNil)
+	qt.Assert(t, got.Status.GetCondition(v1alpha1.TypePeerAuthenticationReady).Reason, qt.Equals, v1alpha1.ReasonCreated)
+}
+
+func TestReconciler_ReconcileSidecar(t *testing.T) {
+	cases := map[string]struct {
+		istio *v1alpha1.ProfileIstio
+		opts  []ReconcilerOption
+		want  []string
+	}{
+		"UsesTheDefaultEgressHosts": {
+			want: []string{"./*", "istio-system/*", "kubeflow/*"},
+		},
+		"UsesTheConfiguredEgressHosts": {
+			opts: []ReconcilerOption{WithSidecarEgressHosts("./*", "istio-system/*")},
+			want: []string{"./*", "istio-system/*"},
+		},
+		"AddsTheProfileEgressHosts": {
+			istio: &v1alpha1.ProfileIstio{EgressHosts: []string{"model-gateway/*", "./*"}},
+			opts:  []ReconcilerOption{WithSidecarEgressHosts("./*", "istio-system/*")},
+			want:  []string{"./*", "istio-system/*", "model-gateway/*"},
+		},
+	}
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+	qt.Assert(t, istionetworking.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	for name, subtest := range cases {
+		t.Run(name, func(t *testing.T) {
+			profile := &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+					Istio: subtest.istio,
+				},
+			}
+			k8s := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(profile).Build()
+
+			opts := append(subtest.opts,
+				WithSidecarEgressEnabled(),
+				WithRecorder(event.NewAPIRecorder(record.NewFakeRecorder(100))),
+			)
+			r := NewReconciler(manager.FromClient(k8s), opts...)
+			_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(profile)})
+			qt.Assert(t, err, qt.IsNil)
+
+			sidecar := &istionetworking.Sidecar{}
+			qt.Assert(t, k8s.Get(ctx, client.ObjectKey{Namespace: "starlord", Name: "kf-sidecar"}, sidecar), qt.IsNil)
+			qt.Assert(t, sidecar.Spec.Egress, qt.HasLen, 1)
+			qt.Assert(t, sidecar.Spec.Egress[0].Hosts, qt.DeepEquals, subtest.want)
+			qt.Assert(t, metav1.GetControllerOf(sidecar).Name, qt.Equals, "starlord")
+
+			got := &v1alpha1.Profile{}
+			qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(profile), got), qt.IsNil)
+			qt.Assert(t, got.Status.GetCondition(v1alpha1.TypeSidecarReady).Reason, qt.Equals, v1alpha1.ReasonCreated)
+		})
+	}
+}
+
+func TestReconciler_ReconcileMissingTemplate(t *testing.T) {
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	profile := &v1alpha1.Profile{
+		ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+		Spec: v1alpha1.ProfileSpec{
+			Owner:       rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+			TemplateRef: &corev1.LocalObjectReference{Name: "ml-team"},
+		},
+	}
+	k8s := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(profile).Build()
+
+	r := NewReconciler(manager.FromClient(k8s),
+		WithDefaultNamespaceReconcileFunc(),
+		WithRecorder(event.NewAPIRecorder(record.NewFakeRecorder(100))),
+	)
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(profile)})
+	qt.Assert(t, err, qt.ErrorMatches, `failed to get profile template "ml-team": .*not found`)
+
+	got := &v1alpha1.Profile{}
+	qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(profile), got), qt.IsNil)
+	qt.Assert(t, got.Status.GetCondition(v1alpha1.TypeNamespaceReady).Status, qt.Equals, corev1.ConditionFalse)
+}
+
+func TestReconciler_Conditions(t *testing.T) {
+	cases := map[string]struct {
+		profile  *v1alpha1.Profile
+		opts     []ReconcilerOption
+		initObjs []client.Object
+		want     []xpv1.Condition
+	}{
+		"SetsReadyWhenAllStepsSucceed": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "starlord",
+					Generation: 3,
+				},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{
+						Kind: "User",
+						Name: "starlord@guardians.net",
+					},
+				},
+			},
+			opts: []ReconcilerOption{
+				WithDefaultNamespaceReconcileFunc(),
+				WithDefaultContributorReconcilerFunc(),
+				WithResourceQuotaEnabled(),
+			},
+			want: []xpv1.Condition{{
+				Type:   v1alpha1.TypeNamespaceReady,
+				Status: corev1.ConditionTrue,
+				Reason: v1alpha1.ReasonCreated,
+			}, {
+				Type:   v1alpha1.TypeOwnerContributorReady,
+				Status: corev1.ConditionTrue,
+				Reason: v1alpha1.ReasonCreated,
+			}, {
+				Type:   v1alpha1.TypeQuotaReady,
+				Status: corev1.ConditionTrue,
+				Reason: v1alpha1.ReasonCreated,
+			}, {
+				Type:   v1alpha1.TypeSynced,
+				Status: corev1.ConditionTrue,
+				Reason: v1alpha1.ReasonReconcileSuccess,
+			}, {
+				Type:   v1alpha1.TypeReady,
+				Status: corev1.ConditionTrue,
+				Reason: v1alpha1.ReasonAvailable,
+			}},
+		},
+		"SetsNotReadyWhenNamespaceIsNotOwned": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "starlord",
+					Generation: 1,
+				},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{
+						Kind: "User",
+						Name: "starlord@guardians.net",
+					},
+				},
+			},
+			initObjs: []client.Object{
+				&corev1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "starlord",
+					},
+				},
+			},
+			opts: []ReconcilerOption{
+				WithDefaultNamespaceReconcileFunc(),
+				WithResourceQuotaEnabled(),
+			},
+			want: []xpv1.Condition{{
+				Type:    v1alpha1.TypeNamespaceReady,
+				Status:  corev1.ConditionFalse,
+				Reason:  v1alpha1.ReasonStopped,
+				Message: "refusing to update namespace not owned by profile",
+			}, {
+				Type:   v1alpha1.TypeSynced,
+				Status: corev1.ConditionTrue,
+				Reason: v1alpha1.ReasonReconcileSuccess,
+			}, {
+				Type:    v1alpha1.TypeReady,
+				Status:  corev1.ConditionFalse,
+				Reason:  v1alpha1.ReasonUnavailable,
+				Message: "NamespaceReady is False: refusing to update namespace not owned by profile",
+			}},
+		},
+		"MarksRemainingStepsPendingWhenStopped": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "starlord",
+					Generation: 2,
+				},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{
+						Kind: "User",
+						Name: "starlord@guardians.net",
+					},
+				},
+				Status: v1alpha1.ProfileStatus{
+					ConditionedStatus: xpv1.ConditionedStatus{
+						Conditions: []xpv1.Condition{{
+							Type:   v1alpha1.TypeQuotaReady,
+							Status: corev1.ConditionTrue,
+							Reason: v1alpha1.ReasonAvailable,
+						}},
+					},
+				},
+			},
+			initObjs: []client.Object{
+				&corev1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "starlord",
+					},
+				},
+			},
+			opts: []ReconcilerOption{
+				WithDefaultNamespaceReconcileFunc(),
+				WithResourceQuotaEnabled(),
+			},
+			want: []xpv1.Condition{{
+				Type:    v1alpha1.TypeQuotaReady,
+				Status:  corev1.ConditionUnknown,
+				Reason:  v1alpha1.ReasonPending,
+				Message: "waiting for NamespaceReady",
+			}, {
+				Type:    v1alpha1.TypeNamespaceReady,
+				Status:  corev1.ConditionFalse,
+				Reason:  v1alpha1.ReasonStopped,
+				Message: "refusing to update namespace not owned by profile",
+			}, {
+				Type:   v1alpha1.TypeSynced,
+				Status: corev1.ConditionTrue,
+				Reason: v1alpha1.ReasonReconcileSuccess,
+			}, {
+				Type:    v1alpha1.TypeReady,
+				Status:  corev1.ConditionFalse,
+				Reason:  v1alpha1.ReasonUnavailable,
+				Message: "NamespaceReady is False: refusing to update namespace not owned by profile",
+			}},
+		},
+	}
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	for name, subtest := range cases {
+		t.Run(name, func(t *testing.T) {
+
+			k8s := fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(subtest.profile).
+				WithObjects(subtest.initObjs...).
+				Build()
+
+			opts := append(subtest.opts, WithRecorder(event.NewAPIRecorder(record.NewFakeRecorder(100))))
+			r := NewReconciler(manager.FromClient(k8s), opts...)
+			res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(subtest.profile)})
+			qt.Assert(t, err, qt.IsNil)
+			qt.Assert(t, res, qt.Equals, ctrl.Result{})
+
+			got := &v1alpha1.Profile{}
+			qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(subtest.profile), got), qt.IsNil)
+			qt.Assert(t, got.Status.Conditions, qt.CmpEquals(
+				cmpopts.IgnoreFields(xpv1.Condition{}, "LastTransitionTime"),
+			), subtest.want)
+			qt.Assert(t, got.Status.ObservedGeneration, qt.Equals, subtest.profile.Generation)
+		})
+	}
+}
+
+func TestReconciler_StatusContributors(t *testing.T) {
+	cases := map[string]struct {
+		profile  *v1alpha1.Profile
+		opts     []ReconcilerOption
+		initObjs []client.Object
+		want     []v1alpha1.ProfileContributor
+	}{
+		"ListsContributorsWithReadyState": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "starlord",
+				},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{
+						Kind: "User",
+						Name: "starlord@guardians.net",
+					},
+				},
+			},
+			initObjs: []client.Object{
+				&v1alpha1.Contributor{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "starlord",
+						Namespace: "starlord",
+					},
+					Spec: v1alpha1.ContributorSpec{
+						Name: "starlord@guardians.net",
+						Role: v1alpha1.ContributorRoleOwner,
+					},
+					Status: v1alpha1.ContributorStatus{
+						ConditionedStatus: xpv1.ConditionedStatus{
+							Conditions: []xpv1.Condition{{
+								Type:   v1alpha1.TypeReady,
+								Status: corev1.ConditionTrue,
+							}},
+						},
+					},
+				},
+				&v1alpha1.Contributor{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "gamora",
+						Namespace: "starlord",
+					},
+					Spec: v1alpha1.ContributorSpec{
+						Name: "gamora@guardians.net",
+						Role: v1alpha1.ContributorRoleContributor,
+					},
+					Status: v1alpha1.ContributorStatus{
+						ConditionedStatus: xpv1.ConditionedStatus{
+							Conditions: []xpv1.Condition{{
+								Type:   v1alpha1.TypeReady,
+								Status: corev1.ConditionFalse,
+							}},
+						},
+					},
+				},
+			},
+			want: []v1alpha1.ProfileContributor{{
+				Name:  "gamora",
+				User:  "gamora@guardians.net",
+				Role:  v1alpha1.ContributorRoleContributor,
+				Ready: corev1.ConditionFalse,
+			}, {
+				Name:  "starlord",
+				User:  "starlord@guardians.net",
+				Role:  v1alpha1.ContributorRoleOwner,
+				Ready: corev1.ConditionTrue,
+			}},
+		},
+	}
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	for name, subtest := range cases {
+		t.Run(name, func(t *testing.T) {
+
+			k8s := fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(subtest.profile).
+				WithObjects(subtest.initObjs...).
+				Build()
+
+			opts := append(subtest.opts, WithRecorder(event.NewAPIRecorder(record.NewFakeRecorder(100))))
+			r := NewReconciler(manager.FromClient(k8s), opts...)
+			res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(subtest.profile)})
+			qt.Assert(t, err, qt.IsNil)
+			qt.Assert(t, res, qt.Equals, ctrl.Result{})
+
+			got := &v1alpha1.Profile{}
+			qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(subtest.profile), got), qt.IsNil)
+			qt.Assert(t, got.Status.Contributors, qt.DeepEquals, subtest.want)
+		})
+	}
+}
+
+func TestReconciler_Events(t *testing.T) {
+	cases := map[string]struct {
+		profile  *v1alpha1.Profile
+		opts     []ReconcilerOption
+		initObjs []client.Object
+		want     []string
+	}{
+		"RecordsCreatedEvents": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "starlord",
+				},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{
+						Kind: "User",
+						Name: "starlord@guardians.net",
+					},
+				},
+			},
+			opts: []ReconcilerOption{
+				WithDefaultNamespaceReconcileFunc(),
+				WithDefaultContributorReconcilerFunc(),
+				WithResourceQuotaEnabled(),
+			},
+			want: []string{
+				"Normal Created created namespace",
+				"Normal Created created owner contributor",
+				"Normal Created created resource quota",
+			},
+		},
+		"RecordsAWarningWhenNamespaceIsNotOwned": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "starlord",
+				},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{
+						Kind: "User",
+						Name: "starlord@guardians.net",
+					},
+				},
+			},
+			initObjs: []client.Object{
+				&corev1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "starlord",
+					},
+				},
+			},
+			opts: []ReconcilerOption{
+				WithDefaultNamespaceReconcileFunc(),
+			},
+			want: []string{
+				"Warning Refused refusing to update namespace not owned by profile",
+			},
+		},
+	}
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	for name, subtest := range cases {
+		t.Run(name, func(t *testing.T) {
+
+			k8s := fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(subtest.profile).
+				WithObjects(subtest.initObjs...).
+				Build()
+
+			recorder := record.NewFakeRecorder(100)
+			opts := append(subtest.opts, WithRecorder(event.NewAPIRecorder(recorder)))
+			r := NewReconciler(manager.FromClient(k8s), opts...)
+			res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(subtest.profile)})
+			qt.Assert(t, err, qt.IsNil)
+			qt.Assert(t, res, qt.Equals, ctrl.Result{})
+
+			close(recorder.Events)
+			got := make([]string, 0)
+			for e := range recorder.Events {
+				got = append(got, e)
+			}
+			qt.Assert(t, got, qt.DeepEquals, subtest.want)
+		})
+	}
+}
+
+// fakePlugin records the plugin specs it is applied and revoked with
+type fakePlugin struct {
+	err     error
+	applied []string
+	revoked []string
+}
+
+func (p *fakePlugin) Apply(_ context.Context, _ *v1alpha1.Profile, spec *runtime.RawExtension) error {
+	p.applied = append(p.applied, string(spec.Raw))
+	return p.err
+}
+
+func (p *fakePlugin) Revoke(_ context.Context, _ *v1alpha1.Profile, spec *runtime.RawExtension) error {
+	raw := ""
+	if spec != nil {
+		raw = string(spec.Raw)
+	}
+	p.revoked = append(p.revoked, raw)
+	return p.err
+}
+
+func TestReconciler_ReconcilePlugins(t *testing.T) {
+	cases := map[string]struct {
+		plugins     []v1alpha1.Plugin
+		status      []v1alpha1.PluginStatus
+		pluginErr   error
+		wantErr     string
+		wantApplied []string
+		wantRevoked []string
+		wantStatus  []v1alpha1.PluginStatus
+		wantReady   corev1.ConditionStatus
+	}{
+		"AppliesEachPlugin": {
+			plugins: []v1alpha1.Plugin{{
+				TypeMeta: metav1.TypeMeta{Kind: "Fake"},
+				Spec:     &runtime.RawExtension{Raw: []byte(`{"name":"gamora"}`)},
+			}},
+			wantApplied: []string{`{"name":"gamora"}`},
+			wantStatus:  []v1alpha1.PluginStatus{{Kind: "Fake", Ready: corev1.ConditionTrue}},
+			wantReady:   corev1.ConditionTrue,
+		},
+		"RecordsAFailedPlugin": {
+			plugins: []v1alpha1.Plugin{{
+				TypeMeta: metav1.TypeMeta{Kind: "Fake"},
+				Spec:     &runtime.RawExtension{Raw: []byte(`{}`)},
+			}},
+			pluginErr:   errors.New("access denied"),
+			wantErr:     "failed to apply plugin Fake: access denied",
+			wantApplied: []string{`{}`},
+			wantStatus: []v1alpha1.PluginStatus{{
+				Kind:    "Fake",
+				Ready:   corev1.ConditionFalse,
+				Message: "failed to apply plugin Fake: access denied",
+			}},
+			wantReady: corev1.ConditionFalse,
+		},
+		"RecordsAnUnsupportedPlugin": {
+			plugins: []v1alpha1.Plugin{{
+				TypeMeta: metav1.TypeMeta{Kind: "Unknown"},
+			}, {
+				TypeMeta: metav1.TypeMeta{Kind: "Fake"},
+				Spec:     &runtime.RawExtension{Raw: []byte(`{}`)},
+			}},
+			wantErr:     `unsupported plugin kind "Unknown"`,
+			wantApplied: []string{`{}`},
+			wantStatus: []v1alpha1.PluginStatus{{
+				Kind:    "Unknown",
+				Ready:   corev1.ConditionFalse,
+				Message: `unsupported plugin kind "Unknown"`,
+			}, {
+				Kind:  "Fake",
+				Ready: corev1.ConditionTrue,
+			}},
+			wantReady: corev1.ConditionFalse,
+		},
+		"SkipsAProfileWithoutPlugins": {
+			wantReady: corev1.ConditionUnknown,
+		},
+		"RevokesARemovedPlugin": {
+			plugins: []v1alpha1.Plugin{{
+				TypeMeta: metav1.TypeMeta{Kind: "Other"},
+				Spec:     &runtime.RawExtension{Raw: []byte(`{}`)},
+			}},
+			status: []v1alpha1.PluginStatus{
+				{Kind: "Fake", Ready: corev1.ConditionTrue},
+				{Kind: "Other", Ready: corev1.ConditionTrue},
+			},
+			wantApplied: []string{`{}`},
+			wantRevoked: []string{""},
+			wantStatus:  []v1alpha1.PluginStatus{{Kind: "Other", Ready: corev1.ConditionTrue}},
+			wantReady:   corev1.ConditionTrue,
+		},
+		"RevokesTheLastRemovedPlugin": {
+			status:      []v1alpha1.PluginStatus{{Kind: "Fake", Ready: corev1.ConditionTrue}},
+			wantRevoked: []string{""},
+			wantReady:   corev1.ConditionTrue,
+		},
+		"KeepsTheStatusWhenRevokeFails": {
+			status:      []v1alpha1.PluginStatus{{Kind: "Fake", Ready: corev1.ConditionTrue}},
+			pluginErr:   errors.New("access denied"),
+			wantErr:     "failed to revoke plugin Fake: access denied",
+			wantRevoked: []string{""},
+			wantStatus:  []v1alpha1.PluginStatus{{Kind: "Fake", Ready: corev1.ConditionTrue}},
+			wantReady:   corev1.ConditionFalse,
+		},
+	}
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	for name, subtest := range cases {
+		t.Run(name, func(t *testing.T) {
+			profile := &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner:   rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+					Plugins: subtest.plugins,
+				},
+				Status: v1alpha1.ProfileStatus{Plugins: subtest.status},
+			}
+			k8s := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(profile).Build()
+
+			p := &fakePlugin{err: subtest.pluginErr}
+			other := &fakePlugin{}
+			r := NewReconciler(manager.FromClient(k8s),
+				WithPluginsEnabled(),
+				WithPlugin("Fake", p),
+				WithPlugin("Other", other),
+				WithRecorder(event.NewAPIRecorder(record.NewFakeRecorder(100))),
+			)
+			_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(profile)})
+			if subtest.wantErr != "" {
+				qt.Assert(t, err, qt.ErrorMatches, subtest.wantErr)
+			} else {
+				qt.Assert(t, err, qt.IsNil)
+			}
+			qt.Assert(t, append(p.applied, other.applied...), qt.DeepEquals, subtest.wantApplied)
+			qt.Assert(t, append(p.revoked, other.revoked...), qt.DeepEquals, subtest.wantRevoked)
+
+			got := &v1alpha1.Profile{}
+			qt.Assert(t, k8s.Get(ctx, client.ObjectKeyFromObject(profile), got), qt.IsNil)
+			qt.Assert(t, got.Status.Plugins, qt.DeepEquals, subtest.wantStatus)
+			qt.Assert(t, got.Status.GetCondition(v1alpha1.TypePluginsReady).Status, qt.Equals, subtest.wantReady)
+		})
+	}
+}
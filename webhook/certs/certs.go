@@ -0,0 +1,189 @@
+package certs
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	errGenerateKey  = "failed to generate private key"
+	errCreateCert   = "failed to create certificate"
+	errParseCert    = "failed to parse certificate"
+	errParseKey     = "failed to parse private key"
+	errNoPEMBlock   = "no PEM block found"
+	errMarshalKey   = "failed to marshal private key"
+	errSerialNumber = "failed to generate serial number"
+)
+
+// KeyPair is a PEM encoded certificate and private key
+type KeyPair struct {
+	Cert []byte
+	Key  []byte
+}
+
+// NewCA returns a self signed certificate authority valid for the supplied duration
+func NewCA(commonName string, validFor time.Duration) (*KeyPair, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, errGenerateKey)
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(validFor),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, errors.Wrap(err, errCreateCert)
+	}
+	return encode(der, key)
+}
+
+// NewServingCert returns a serving certificate for the supplied DNS names signed
+// by the certificate authority
+func NewServingCert(ca *KeyPair, dnsNames []string, validFor time.Duration) (*KeyPair, error) {
+	caCert, err := ParseCert(ca.Cert)
+	if err != nil {
+		return nil, err
+	}
+	caKey, err := parseKey(ca.Key)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, errGenerateKey)
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:     dnsNames,
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(validFor),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, key.Public(), caKey)
+	if err != nil {
+		return nil, errors.Wrap(err, errCreateCert)
+	}
+	return encode(der, key)
+}
+
+// ParseCert parses the first certificate in PEM encoded data
+func ParseCert(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New(errNoPEMBlock)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	return cert, errors.Wrap(err, errParseCert)
+}
+
+// ValidFor returns true when the serving certificate is signed by the certificate
+// authority, is valid for all dnsNames, and does not expire before the supplied time
+func ValidFor(ca []byte, serving *KeyPair, dnsNames []string, at time.Time) bool {
+	if _, err := verifyKeyPair(serving); err != nil {
+		return false
+	}
+	cert, err := ParseCert(serving.Cert)
+	if err != nil {
+		return false
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return false
+	}
+	for _, name := range dnsNames {
+		_, err := cert.Verify(x509.VerifyOptions{
+			DNSName:     name,
+			Roots:       pool,
+			CurrentTime: at,
+		})
+		if err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Bundle concatenates PEM encoded certificates, skipping empty entries
+func Bundle(certs ...[]byte) []byte {
+	buf := &bytes.Buffer{}
+	for _, c := range certs {
+		if len(c) == 0 {
+			continue
+		}
+		buf.Write(bytes.TrimSpace(c))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+func verifyKeyPair(kp *KeyPair) (crypto.Signer, error) {
+	key, err := parseKey(kp.Key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := ParseCert(kp.Cert)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok || !pub.Equal(key.Public()) {
+		return nil, errors.New(errParseKey)
+	}
+	return key, nil
+}
+
+func parseKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New(errNoPEMBlock)
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, errParseKey)
+	}
+	return key, nil
+}
+
+func encode(der []byte, key *ecdsa.PrivateKey) (*KeyPair, error) {
+	raw, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, errors.Wrap(err, errMarshalKey)
+	}
+	return &KeyPair{
+		Cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		Key:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: raw}),
+	}, nil
+}
+
+func serialNumber() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	return serial, errors.Wrap(err, errSerialNumber)
+}
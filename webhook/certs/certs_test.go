@@ -0,0 +1,83 @@
+package certs
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestValidFor(t *testing.T) {
+	dnsNames := []string{"profile-manager-webhook.kubeflow-system.svc"}
+
+	ca, err := NewCA("test-ca", 24*time.Hour)
+	qt.Assert(t, err, qt.IsNil)
+	other, err := NewCA("other-ca", 24*time.Hour)
+	qt.Assert(t, err, qt.IsNil)
+	serving, err := NewServingCert(ca, dnsNames, time.Hour)
+	qt.Assert(t, err, qt.IsNil)
+
+	cases := map[string]struct {
+		ca       []byte
+		serving  *KeyPair
+		dnsNames []string
+		at       time.Time
+		want     bool
+	}{
+		"IsValidForTheServiceName": {
+			ca:       ca.Cert,
+			serving:  serving,
+			dnsNames: dnsNames,
+			at:       time.Now(),
+			want:     true,
+		},
+		"IsValidInABundle": {
+			ca:       Bundle(other.Cert, ca.Cert),
+			serving:  serving,
+			dnsNames: dnsNames,
+			at:       time.Now(),
+			want:     true,
+		},
+		"IsNotValidForAnotherName": {
+			ca:       ca.Cert,
+			serving:  serving,
+			dnsNames: []string{"profile-manager-webhook.default.svc"},
+			at:       time.Now(),
+			want:     false,
+		},
+		"IsNotValidForAnotherCA": {
+			ca:       other.Cert,
+			serving:  serving,
+			dnsNames: dnsNames,
+			at:       time.Now(),
+			want:     false,
+		},
+		"IsNotValidAfterExpiry": {
+			ca:       ca.Cert,
+			serving:  serving,
+			dnsNames: dnsNames,
+			at:       time.Now().Add(2 * time.Hour),
+			want:     false,
+		},
+		"IsNotValidWithAMismatchedKey": {
+			ca:       ca.Cert,
+			serving:  &KeyPair{Cert: serving.Cert, Key: ca.Key},
+			dnsNames: dnsNames,
+			at:       time.Now(),
+			want:     false,
+		},
+		"IsNotValidWhenEmpty": {
+			ca:       ca.Cert,
+			serving:  &KeyPair{},
+			dnsNames: dnsNames,
+			at:       time.Now(),
+			want:     false,
+		},
+	}
+
+	for name, tt := range cases {
+		t.Run(name, func(t *testing.T) {
+			qt.Assert(t, ValidFor(tt.ca, tt.serving, tt.dnsNames, tt.at), qt.Equals, tt.want)
+		})
+	}
+}
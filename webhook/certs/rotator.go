@@ -0,0 +1,373 @@
+package certs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/pkg/errors"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// CACertKey is the secret key of the current certificate authority
+	CACertKey = "ca.crt"
+	// CAKeyKey is the secret key of the current certificate authority private key
+	CAKeyKey = "ca.key"
+	// PreviousCACertKey is the secret key of the certificate authority that was
+	// replaced on the last CA rotation. It stays in the CA bundle until it expires
+	// so that replicas still serving the old certificate are trusted
+	PreviousCACertKey = "ca.previous.crt"
+
+	errReadSecret        = "failed to read certificate secret"
+	errWriteSecret       = "failed to write certificate secret"
+	errWriteCertDir      = "failed to write serving certificate to certificate directory"
+	errInjectValidating  = "failed to inject CA bundle into ValidatingWebhookConfiguration"
+	errInjectMutating    = "failed to inject CA bundle into MutatingWebhookConfiguration"
+	errInjectConversion  = "failed to inject CA bundle into CustomResourceDefinition"
+	errGenerateCA        = "failed to generate certificate authority"
+	errGenerateServing   = "failed to generate serving certificate"
+	errFmtNotFoundLogMsg = "%s %q not found, skipping CA bundle injection"
+)
+
+// The certificate Secret is only read and written in the controller namespace,
+// which is set at install time. Its permissions are granted by the
+// profile-manager-webhook-cert Role in config/controller rather than by RBAC
+// markers so that the Role follows the kustomize namespace
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingwebhookconfigurations;mutatingwebhookconfigurations,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch;patch
+
+type RotatorOption func(r *Rotator)
+
+func WithLogger(logger logging.Logger) RotatorOption {
+	return func(r *Rotator) {
+		r.logger = logger
+	}
+}
+
+// WithSecret sets the Secret the certificate authority and serving certificate
+// are stored in
+func WithSecret(namespace, name string) RotatorOption {
+	return func(r *Rotator) {
+		r.secret = client.ObjectKey{Namespace: namespace, Name: name}
+	}
+}
+
+// WithService sets the Service the webhook server is exposed through. The serving
+// certificate is valid for the Service DNS names
+func WithService(namespace, name string) RotatorOption {
+	return func(r *Rotator) {
+		r.dnsNames = []string{
+			fmt.Sprintf("%s.%s.svc", name, namespace),
+			fmt.Sprintf("%s.%s.svc.cluster.local", name, namespace),
+		}
+	}
+}
+
+// WithCertDir sets the directory the serving certificate is written to. This
+// must be the CertDir of the webhook server
+func WithCertDir(dir string) RotatorOption {
+	return func(r *Rotator) {
+		r.certDir = dir
+	}
+}
+
+func WithValidatingWebhookConfigurations(names ...string) RotatorOption {
+	return func(r *Rotator) {
+		r.validating = append(r.validating, names...)
+	}
+}
+
+func WithMutatingWebhookConfigurations(names ...string) RotatorOption {
+	return func(r *Rotator) {
+		r.mutating = append(r.mutating, names...)
+	}
+}
+
+// WithConversionCRDs sets the CustomResourceDefinitions with conversion webhooks
+// served by the webhook server
+func WithConversionCRDs(names ...string) RotatorOption {
+	return func(r *Rotator) {
+		r.crds = append(r.crds, names...)
+	}
+}
+
+// WithValidity sets how long the certificate authority and serving
+// certificates are valid for
+func WithValidity(ca, serving time.Duration) RotatorOption {
+	return func(r *Rotator) {
+		r.caValidity = ca
+		r.servingValidity = serving
+	}
+}
+
+// WithRotation sets how long before expiry certificates are rotated and how
+// often they are checked
+func WithRotation(before, interval time.Duration) RotatorOption {
+	return func(r *Rotator) {
+		r.rotateBefore = before
+		r.interval = interval
+	}
+}
+
+// NewRotator returns a Rotator that manages the webhook serving certificate
+// without an external certificate issuer
+func NewRotator(cli client.Client, opts ...RotatorOption) *Rotator {
+	r := &Rotator{
+		client:          cli,
+		logger:          logging.NewNopLogger(),
+		caValidity:      10 * 365 * 24 * time.Hour,
+		servingValidity: 365 * 24 * time.Hour,
+		rotateBefore:    30 * 24 * time.Hour,
+		interval:        time.Hour,
+		now:             time.Now,
+	}
+	for _, f := range opts {
+		f(r)
+	}
+	return r
+}
+
+// Rotator generates a certificate authority and serving certificate into a
+// Secret, rotates them before they expire, writes the serving certificate to
+// the webhook server certificate directory and injects the CA bundle into the
+// webhook configurations and CustomResourceDefinitions it owns.
+type Rotator struct {
+	client client.Client
+	logger logging.Logger
+
+	secret   client.ObjectKey
+	dnsNames []string
+	certDir  string
+
+	validating []string
+	mutating   []string
+	crds       []string
+
+	caValidity      time.Duration
+	servingValidity time.Duration
+	rotateBefore    time.Duration
+	interval        time.Duration
+
+	now func() time.Time
+}
+
+// Start checks the certificates every interval until the context is done
+func (r *Rotator) Start(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := r.Reconcile(ctx); err != nil {
+				r.logger.Info("failed to reconcile webhook certificates", "error", err.Error())
+			}
+		}
+	}
+}
+
+// NeedLeaderElection is false because every replica must write the serving
+// certificate for its own webhook server
+func (r *Rotator) NeedLeaderElection() bool {
+	return false
+}
+
+// Reconcile ensures the certificates are valid, then injects the CA bundle and
+// writes the serving certificate. It should be called once before the webhook
+// server is started.
+func (r *Rotator) Reconcile(ctx context.Context) error {
+	secret := &corev1.Secret{}
+	err := r.client.Get(ctx, r.secret, secret)
+	if client.IgnoreNotFound(err) != nil {
+		return errors.Wrap(err, errReadSecret)
+	}
+	exists := err == nil
+
+	changed, err := r.ensure(secret)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !exists:
+		secret.Name = r.secret.Name
+		secret.Namespace = r.secret.Namespace
+		secret.Type = corev1.SecretTypeTLS
+		if err := r.client.Create(ctx, secret); err != nil {
+			if !apierrors.IsAlreadyExists(err) {
+				return errors.Wrap(err, errWriteSecret)
+			}
+			// another replica created the secret first
+			return r.Reconcile(ctx)
+		}
+		r.logger.Debug("created webhook certificate secret")
+	case changed:
+		if err := r.client.Update(ctx, secret); err != nil {
+			if !apierrors.IsConflict(err) {
+				return errors.Wrap(err, errWriteSecret)
+			}
+			// another replica rotated the certificates first
+			return r.Reconcile(ctx)
+		}
+		r.logger.Debug("rotated webhook certificates")
+	}
+
+	// The CA bundle is injected before the serving certificate is written so
+	// that the API server trusts a rotated CA before the webhook server
+	// presents a certificate signed by it
+	if err := r.inject(ctx, Bundle(secret.Data[CACertKey], secret.Data[PreviousCACertKey])); err != nil {
+		return err
+	}
+	return errors.Wrap(r.writeCertDir(secret), errWriteCertDir)
+}
+
+// ensure rotates the certificate authority and serving certificate stored in the
+// secret when they are missing, invalid or about to expire. It returns true when
+// the secret data was changed
+func (r *Rotator) ensure(secret *corev1.Secret) (bool, error) {
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	changed := false
+	deadline := r.now().Add(r.rotateBefore)
+
+	ca := &KeyPair{Cert: secret.Data[CACertKey], Key: secret.Data[CAKeyKey]}
+	if _, err := verifyKeyPair(ca); err != nil || !notExpiredAt(ca.Cert, deadline) {
+		next, err := NewCA(fmt.Sprintf("%s-ca", r.secret.Name), r.caValidity)
+		if err != nil {
+			return false, errors.Wrap(err, errGenerateCA)
+		}
+		delete(secret.Data, PreviousCACertKey)
+		if notExpiredAt(ca.Cert, r.now()) {
+			secret.Data[PreviousCACertKey] = ca.Cert
+		}
+		secret.Data[CACertKey] = next.Cert
+		secret.Data[CAKeyKey] = next.Key
+		ca = next
+		changed = true
+	}
+	if prev, ok := secret.Data[PreviousCACertKey]; ok && !notExpiredAt(prev, r.now()) {
+		delete(secret.Data, PreviousCACertKey)
+		changed = true
+	}
+
+	serving := &KeyPair{Cert: secret.Data[corev1.TLSCertKey], Key: secret.Data[corev1.TLSPrivateKeyKey]}
+	if changed || !ValidFor(ca.Cert, serving, r.dnsNames, deadline) {
+		next, err := NewServingCert(ca, r.dnsNames, r.servingValidity)
+		if err != nil {
+			return false, errors.Wrap(err, errGenerateServing)
+		}
+		secret.Data[corev1.TLSCertKey] = next.Cert
+		secret.Data[corev1.TLSPrivateKeyKey] = next.Key
+		changed = true
+	}
+	return changed, nil
+}
+
+// writeCertDir writes the serving certificate to the certificate directory when it
+// differs from the certificate on disk. The webhook server watches the files
+// and reloads them
+func (r *Rotator) writeCertDir(secret *corev1.Secret) error {
+	if r.certDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(r.certDir, 0o700); err != nil {
+		return err
+	}
+	files := []string{corev1.TLSPrivateKeyKey, corev1.TLSCertKey}
+	for _, name := range files {
+		path := filepath.Join(r.certDir, name)
+		current, err := os.ReadFile(path)
+		if err == nil && bytes.Equal(current, secret.Data[name]) {
+			continue
+		}
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, secret.Data[name], 0o600); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inject sets the CA bundle on every webhook of the owned webhook configurations
+// and on the conversion webhook of the owned CustomResourceDefinitions
+func (r *Rotator) inject(ctx context.Context, bundle []byte) error {
+	for _, name := range r.validating {
+		config := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+		if err := r.client.Get(ctx, client.ObjectKey{Name: name}, config); err != nil {
+			if apierrors.IsNotFound(err) {
+				r.logger.Debug(fmt.Sprintf(errFmtNotFoundLogMsg, "ValidatingWebhookConfiguration", name))
+				continue
+			}
+			return errors.Wrap(err, errInjectValidating)
+		}
+		patch := client.MergeFrom(config.DeepCopy())
+		for k := range config.Webhooks {
+			config.Webhooks[k].ClientConfig.CABundle = bundle
+		}
+		if err := r.client.Patch(ctx, config, patch); err != nil {
+			return errors.Wrap(err, errInjectValidating)
+		}
+	}
+	for _, name := range r.mutating {
+		config := &admissionregistrationv1.MutatingWebhookConfiguration{}
+		if err := r.client.Get(ctx, client.ObjectKey{Name: name}, config); err != nil {
+			if apierrors.IsNotFound(err) {
+				r.logger.Debug(fmt.Sprintf(errFmtNotFoundLogMsg, "MutatingWebhookConfiguration", name))
+				continue
+			}
+			return errors.Wrap(err, errInjectMutating)
+		}
+		patch := client.MergeFrom(config.DeepCopy())
+		for k := range config.Webhooks {
+			config.Webhooks[k].ClientConfig.CABundle = bundle
+		}
+		if err := r.client.Patch(ctx, config, patch); err != nil {
+			return errors.Wrap(err, errInjectMutating)
+		}
+	}
+	for _, name := range r.crds {
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := r.client.Get(ctx, client.ObjectKey{Name: name}, crd); err != nil {
+			if apierrors.IsNotFound(err) {
+				r.logger.Debug(fmt.Sprintf(errFmtNotFoundLogMsg, "CustomResourceDefinition", name))
+				continue
+			}
+			return errors.Wrap(err, errInjectConversion)
+		}
+		conversion := crd.Spec.Conversion
+		if conversion == nil || conversion.Strategy != apiextensionsv1.WebhookConverter ||
+			conversion.Webhook == nil || conversion.Webhook.ClientConfig == nil {
+			continue
+		}
+		patch := client.MergeFrom(crd.DeepCopy())
+		crd.Spec.Conversion.Webhook.ClientConfig.CABundle = bundle
+		if err := r.client.Patch(ctx, crd, patch); err != nil {
+			return errors.Wrap(err, errInjectConversion)
+		}
+	}
+	return nil
+}
+
+func notExpiredAt(cert []byte, at time.Time) bool {
+	parsed, err := ParseCert(cert)
+	return err == nil && at.Before(parsed.NotAfter)
+}
+
+var (
+	_ manager.Runnable               = &Rotator{}
+	_ manager.LeaderElectionRunnable = &Rotator{}
+)
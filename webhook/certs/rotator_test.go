@@ -0,0 +1,291 @@
+package certs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+)
+
+const (
+	testNamespace = "kubeflow-system"
+	testSecret    = "profile-manager-webhook-cert"
+	testService   = "profile-manager-webhook"
+	testWebhook   = "profile-manager-validating-webhook"
+	testCRD       = "profiles.kubeflow.org"
+)
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	qt.Assert(t, clientgoscheme.AddToScheme(scheme), qt.IsNil)
+	qt.Assert(t, apiextensionsv1.AddToScheme(scheme), qt.IsNil)
+	return scheme
+}
+
+func validatingWebhook() *admissionregistrationv1.ValidatingWebhookConfiguration {
+	none := admissionregistrationv1.SideEffectClassNone
+	return &admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: testWebhook},
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{{
+			Name:                    "vprofile.kubeflow.org",
+			AdmissionReviewVersions: []string{"v1"},
+			SideEffects:             &none,
+			ClientConfig: admissionregistrationv1.WebhookClientConfig{
+				Service: &admissionregistrationv1.ServiceReference{
+					Namespace: testNamespace,
+					Name:      testService,
+					Path:      pointer.String("/validate-kubeflow-org-v1alpha1-profile"),
+				},
+			},
+		}},
+	}
+}
+
+func conversionCRD() *apiextensionsv1.CustomResourceDefinition {
+	return &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: testCRD},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: "kubeflow.org",
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
+				Plural:   "profiles",
+				Singular: "profile",
+				Kind:     "Profile",
+				ListKind: "ProfileList",
+			},
+			Scope: apiextensionsv1.ClusterScoped,
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{{
+				Name:    "v1alpha1",
+				Served:  true,
+				Storage: true,
+				Schema: &apiextensionsv1.CustomResourceValidation{
+					OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+						Type:                   "object",
+						XPreserveUnknownFields: pointer.Bool(true),
+					},
+				},
+			}},
+			Conversion: &apiextensionsv1.CustomResourceConversion{
+				Strategy: apiextensionsv1.WebhookConverter,
+				Webhook: &apiextensionsv1.WebhookConversion{
+					ConversionReviewVersions: []string{"v1"},
+					ClientConfig: &apiextensionsv1.WebhookClientConfig{
+						Service: &apiextensionsv1.ServiceReference{
+							Namespace: testNamespace,
+							Name:      testService,
+							Path:      pointer.String("/convert"),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newRotator(cli client.Client, certDir string, opts ...RotatorOption) *Rotator {
+	opts = append([]RotatorOption{
+		WithSecret(testNamespace, testSecret),
+		WithService(testNamespace, testService),
+		WithCertDir(certDir),
+		WithValidatingWebhookConfigurations(testWebhook),
+		WithConversionCRDs(testCRD),
+	}, opts...)
+	return NewRotator(cli, opts...)
+}
+
+// assertInjected checks the serving certificate on disk is trusted by the CA
+// bundle injected into the webhook configuration and CRD
+func assertInjected(t *testing.T, ctx context.Context, cli client.Client, certDir string) *corev1.Secret {
+	t.Helper()
+
+	secret := &corev1.Secret{}
+	qt.Assert(t, cli.Get(ctx, client.ObjectKey{Namespace: testNamespace, Name: testSecret}, secret), qt.IsNil)
+
+	cert, err := os.ReadFile(filepath.Join(certDir, corev1.TLSCertKey))
+	qt.Assert(t, err, qt.IsNil)
+	key, err := os.ReadFile(filepath.Join(certDir, corev1.TLSPrivateKeyKey))
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, cert, qt.DeepEquals, secret.Data[corev1.TLSCertKey])
+
+	config := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+	qt.Assert(t, cli.Get(ctx, client.ObjectKey{Name: testWebhook}, config), qt.IsNil)
+	bundle := config.Webhooks[0].ClientConfig.CABundle
+	dnsNames := []string{testService + "." + testNamespace + ".svc"}
+	qt.Assert(t, ValidFor(bundle, &KeyPair{Cert: cert, Key: key}, dnsNames, time.Now()), qt.IsTrue)
+
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	qt.Assert(t, cli.Get(ctx, client.ObjectKey{Name: testCRD}, crd), qt.IsNil)
+	qt.Assert(t, crd.Spec.Conversion.Webhook.ClientConfig.CABundle, qt.DeepEquals, bundle)
+	return secret
+}
+
+func TestRotator_Reconcile(t *testing.T) {
+	ctx := context.Background()
+
+	cases := map[string]struct {
+		opts []RotatorOption
+		// prepare runs against the secret after the first reconcile
+		prepare func(secret *corev1.Secret)
+		// rotated is the set of secret keys expected to change on the second reconcile
+		rotated []string
+		// previousCA expects the replaced CA to be kept in the bundle
+		previousCA bool
+	}{
+		"KeepsValidCertificates": {},
+		"RotatesAnExpiringServingCert": {
+			opts:    []RotatorOption{WithValidity(365*24*time.Hour, 24*time.Hour), WithRotation(48*time.Hour, time.Hour)},
+			rotated: []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey},
+		},
+		"RotatesAnExpiringCA": {
+			opts:       []RotatorOption{WithValidity(24*time.Hour, time.Hour), WithRotation(48*time.Hour, time.Hour)},
+			rotated:    []string{CACertKey, CAKeyKey, corev1.TLSCertKey, corev1.TLSPrivateKeyKey},
+			previousCA: true,
+		},
+		"ReplacesACorruptServingCert": {
+			prepare: func(secret *corev1.Secret) {
+				secret.Data[corev1.TLSCertKey] = []byte("not a certificate")
+			},
+			rotated: []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey},
+		},
+		"ReplacesAServingCertForAnotherService": {
+			prepare: func(secret *corev1.Secret) {
+				ca := &KeyPair{Cert: secret.Data[CACertKey], Key: secret.Data[CAKeyKey]}
+				serving, err := NewServingCert(ca, []string{"other.default.svc"}, time.Hour)
+				if err != nil {
+					panic(err)
+				}
+				secret.Data[corev1.TLSCertKey] = serving.Cert
+				secret.Data[corev1.TLSPrivateKeyKey] = serving.Key
+			},
+			rotated: []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey},
+		},
+	}
+
+	for name, tt := range cases {
+		t.Run(name, func(t *testing.T) {
+			certDir := t.TempDir()
+			cli := fake.NewClientBuilder().
+				WithScheme(newScheme(t)).
+				WithObjects(validatingWebhook(), conversionCRD()).
+				Build()
+
+			qt.Assert(t, newRotator(cli, certDir, tt.opts...).Reconcile(ctx), qt.IsNil)
+			first := assertInjected(t, ctx, cli, certDir)
+			qt.Assert(t, first.Type, qt.Equals, corev1.SecretTypeTLS)
+
+			if tt.prepare != nil {
+				secret := first.DeepCopy()
+				tt.prepare(secret)
+				qt.Assert(t, cli.Update(ctx, secret), qt.IsNil)
+				first = secret
+			}
+
+			qt.Assert(t, newRotator(cli, certDir, tt.opts...).Reconcile(ctx), qt.IsNil)
+			second := assertInjected(t, ctx, cli, certDir)
+
+			rotated := make(map[string]bool)
+			for _, key := range tt.rotated {
+				rotated[key] = true
+			}
+			for _, key := range []string{CACertKey, CAKeyKey, corev1.TLSCertKey, corev1.TLSPrivateKeyKey} {
+				if rotated[key] {
+					qt.Check(t, second.Data[key], qt.Not(qt.DeepEquals), first.Data[key], qt.Commentf("key %s", key))
+				} else {
+					qt.Check(t, second.Data[key], qt.DeepEquals, first.Data[key], qt.Commentf("key %s", key))
+				}
+			}
+			if tt.previousCA {
+				qt.Assert(t, second.Data[PreviousCACertKey], qt.DeepEquals, first.Data[CACertKey])
+			} else {
+				qt.Assert(t, second.Data[PreviousCACertKey], qt.IsNil)
+			}
+		})
+	}
+}
+
+func TestRotator_ReconcileSkipsMissingConfigurations(t *testing.T) {
+	ctx := context.Background()
+	certDir := t.TempDir()
+	cli := fake.NewClientBuilder().WithScheme(newScheme(t)).Build()
+
+	qt.Assert(t, newRotator(cli, certDir).Reconcile(ctx), qt.IsNil)
+
+	secret := &corev1.Secret{}
+	qt.Assert(t, cli.Get(ctx, client.ObjectKey{Namespace: testNamespace, Name: testSecret}, secret), qt.IsNil)
+	_, err := os.Stat(filepath.Join(certDir, corev1.TLSCertKey))
+	qt.Assert(t, err, qt.IsNil)
+}
+
+// failingPatchClient fails every patch, e.g. when the controller can't update
+// the webhook configurations
+type failingPatchClient struct {
+	client.Client
+}
+
+func (c *failingPatchClient) Patch(context.Context, client.Object, client.Patch, ...client.PatchOption) error {
+	return errors.New("patch refused")
+}
+
+func TestRotator_ReconcileInjectsBeforeWritingCertDir(t *testing.T) {
+	ctx := context.Background()
+	certDir := t.TempDir()
+	cli := fake.NewClientBuilder().
+		WithScheme(newScheme(t)).
+		WithObjects(validatingWebhook(), conversionCRD()).
+		Build()
+
+	err := newRotator(&failingPatchClient{Client: cli}, certDir).Reconcile(ctx)
+	qt.Assert(t, err, qt.ErrorMatches, errInjectValidating+": patch refused")
+
+	// the serving certificate isn't served until the CA bundle is trusted
+	_, err = os.Stat(filepath.Join(certDir, corev1.TLSCertKey))
+	qt.Assert(t, os.IsNotExist(err), qt.IsTrue)
+
+	qt.Assert(t, newRotator(cli, certDir).Reconcile(ctx), qt.IsNil)
+	assertInjected(t, ctx, cli, certDir)
+}
+
+// TestRotator_Envtest runs the rotator against a real API server. It requires
+// the kubebuilder assets, see the test target in the Makefile
+func TestRotator_Envtest(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("KUBEBUILDER_ASSETS is not set")
+	}
+	ctx := context.Background()
+
+	env := &envtest.Environment{
+		Scheme: newScheme(t),
+		CRDInstallOptions: envtest.CRDInstallOptions{
+			CRDs: []*apiextensionsv1.CustomResourceDefinition{conversionCRD()},
+		},
+	}
+	cfg, err := env.Start()
+	qt.Assert(t, err, qt.IsNil)
+	t.Cleanup(func() { _ = env.Stop() })
+
+	cli, err := client.New(cfg, client.Options{Scheme: env.Scheme})
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, cli.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}}), qt.IsNil)
+	qt.Assert(t, cli.Create(ctx, validatingWebhook()), qt.IsNil)
+
+	certDir := t.TempDir()
+	qt.Assert(t, newRotator(cli, certDir).Reconcile(ctx), qt.IsNil)
+	first := assertInjected(t, ctx, cli, certDir)
+
+	qt.Assert(t, newRotator(cli, certDir).Reconcile(ctx), qt.IsNil)
+	second := assertInjected(t, ctx, cli, certDir)
+	qt.Assert(t, second.Data, qt.DeepEquals, first.Data)
+}
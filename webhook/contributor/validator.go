@@ -0,0 +1,199 @@
+package contributor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/johnhoman/kubeflow-profile-manager/apis/v1alpha1"
+	"github.com/johnhoman/kubeflow-profile-manager/controller"
+	"github.com/johnhoman/kubeflow-profile-manager/controller/manager"
+)
+
+const (
+	errNotContributor = "object is not a Contributor"
+	errReadNamespace  = "failed to read namespace"
+	errReadProfile    = "failed to read profile"
+	errListContrib    = "failed to list contributors"
+)
+
+// +kubebuilder:webhook:path=/validate-kubeflow-org-v1alpha1-contributor,mutating=false,failurePolicy=fail,sideEffects=None,groups=kubeflow.org,resources=contributors,verbs=create;update;delete,versions=v1alpha1,name=vcontributor.kubeflow.org,admissionReviewVersions=v1
+
+func Setup(mgr ctrl.Manager, o controller.Options, opts ...ValidatorOption) error {
+
+	opts = append(opts, WithLogger(o.Logger.WithValues("webhook", "contributor-validator")))
+
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&v1alpha1.Contributor{}).
+		WithValidator(NewValidator(mgr, opts...)).
+		Complete()
+}
+
+type ValidatorOption func(v *Validator)
+
+func WithLogger(logger logging.Logger) ValidatorOption {
+	return func(v *Validator) {
+		v.logger = logger
+	}
+}
+
+// NewValidator returns a Contributor validator that enforces the contributor
+// role, one contributor per user in a namespace, and protects the owner
+// contributor managed by the profile controller
+func NewValidator(mgr manager.Manager, opts ...ValidatorOption) *Validator {
+	v := &Validator{
+		client: mgr.GetClient(),
+		logger: logging.NewNopLogger(),
+	}
+	for _, f := range opts {
+		f(v)
+	}
+	return v
+}
+
+type Validator struct {
+	client client.Client
+	logger logging.Logger
+}
+
+func (v *Validator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	contributor, ok := obj.(*v1alpha1.Contributor)
+	if !ok {
+		return errors.New(errNotContributor)
+	}
+
+	errs := validateSpec(contributor)
+
+	namespace := &corev1.Namespace{}
+	if err := v.client.Get(ctx, client.ObjectKey{Name: contributor.Namespace}, namespace); client.IgnoreNotFound(err) != nil {
+		return errors.Wrap(err, errReadNamespace)
+	}
+	if v1alpha1.GetControllingProfile(namespace) == nil {
+		errs = append(errs, field.Forbidden(
+			field.NewPath("metadata", "namespace"),
+			fmt.Sprintf("namespace %q does not belong to a profile", contributor.Namespace),
+		))
+	}
+
+	fieldErr, err := v.validateUnique(ctx, contributor)
+	if err != nil {
+		return err
+	}
+	if fieldErr != nil {
+		errs = append(errs, fieldErr)
+	}
+	return invalid(contributor, errs)
+}
+
+func (v *Validator) ValidateUpdate(ctx context.Context, _, newObj runtime.Object) error {
+	contributor, ok := newObj.(*v1alpha1.Contributor)
+	if !ok {
+		return errors.New(errNotContributor)
+	}
+
+	errs := validateSpec(contributor)
+	fieldErr, err := v.validateUnique(ctx, contributor)
+	if err != nil {
+		return err
+	}
+	if fieldErr != nil {
+		errs = append(errs, fieldErr)
+	}
+	return invalid(contributor, errs)
+}
+
+// ValidateDelete rejects deleting the owner contributor while the profile that
+// manages it still exists. Deletes from garbage collection after the profile
+// or namespace is deleted are allowed, as are deletes of contributors copied
+// into the additional namespaces of a profile.
+func (v *Validator) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	contributor, ok := obj.(*v1alpha1.Contributor)
+	if !ok {
+		return errors.New(errNotContributor)
+	}
+
+	ref := v1alpha1.GetControllingProfile(contributor)
+	if ref == nil || metav1.HasLabel(contributor.ObjectMeta, v1alpha1.LabelSourceNamespace) {
+		return nil
+	}
+
+	profile := &v1alpha1.Profile{}
+	if err := v.client.Get(ctx, client.ObjectKey{Name: ref.Name}, profile); err != nil {
+		return errors.Wrap(client.IgnoreNotFound(err), errReadProfile)
+	}
+	if profile.UID != ref.UID || profile.DeletionTimestamp != nil {
+		return nil
+	}
+
+	namespace := &corev1.Namespace{}
+	if err := v.client.Get(ctx, client.ObjectKey{Name: contributor.Namespace}, namespace); err != nil {
+		return errors.Wrap(client.IgnoreNotFound(err), errReadNamespace)
+	}
+	if namespace.DeletionTimestamp != nil {
+		return nil
+	}
+
+	v.logger.Debug("rejecting delete of owner contributor", "profile", profile.Name)
+	return apierrors.NewForbidden(
+		schema.GroupResource{Group: v1alpha1.Group, Resource: "contributors"},
+		contributor.Name,
+		errors.Errorf("contributor is the owner of profile %q and is removed with the profile", profile.Name),
+	)
+}
+
+// validateUnique rejects a contributor when another contributor in the same
+// namespace already grants access to the same user. The owner contributor and
+// the copies managed by the profile controller are always accepted.
+func (v *Validator) validateUnique(ctx context.Context, contributor *v1alpha1.Contributor) (*field.Error, error) {
+	if v1alpha1.GetControllingProfile(contributor) != nil {
+		return nil, nil
+	}
+	contributorList := &v1alpha1.ContributorList{}
+	if err := v.client.List(ctx, contributorList, client.InNamespace(contributor.Namespace)); err != nil {
+		return nil, errors.Wrap(err, errListContrib)
+	}
+	for _, item := range contributorList.Items {
+		if item.Name == contributor.Name || item.Spec.Name != contributor.Spec.Name {
+			continue
+		}
+		return field.Duplicate(field.NewPath("spec", "name"), contributor.Spec.Name), nil
+	}
+	return nil, nil
+}
+
+func validateSpec(contributor *v1alpha1.Contributor) field.ErrorList {
+	errs := field.ErrorList{}
+	spec := field.NewPath("spec")
+	if contributor.Spec.Name == "" {
+		errs = append(errs, field.Required(spec.Child("name"), "contributor name must not be empty"))
+	}
+	switch contributor.Spec.Role {
+	case v1alpha1.ContributorRoleOwner, v1alpha1.ContributorRoleContributor:
+	default:
+		errs = append(errs, field.NotSupported(spec.Child("role"), contributor.Spec.Role, []string{
+			v1alpha1.ContributorRoleContributor,
+			v1alpha1.ContributorRoleOwner,
+		}))
+	}
+	return errs
+}
+
+func invalid(contributor *v1alpha1.Contributor, errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(v1alpha1.GroupVersion.WithKind(v1alpha1.ContributorKind).GroupKind(), contributor.Name, errs)
+}
+
+var _ admission.CustomValidator = &Validator{}
@@ -0,0 +1,209 @@
+package contributor
+
+import (
+	"context"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/johnhoman/kubeflow-profile-manager/apis/v1alpha1"
+	"github.com/johnhoman/kubeflow-profile-manager/controller/manager"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func profileNamespace() *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "starlord",
+			OwnerReferences: []metav1.OwnerReference{{
+				Name:               "starlord",
+				Kind:               "Profile",
+				APIVersion:         "kubeflow.org/v1alpha1",
+				UID:                "1234",
+				Controller:         pointer.Bool(true),
+				BlockOwnerDeletion: pointer.Bool(true),
+			}},
+		},
+	}
+}
+
+func TestValidator_ValidateCreate(t *testing.T) {
+
+	cases := map[string]struct {
+		contributor *v1alpha1.Contributor
+		initObjs    []client.Object
+		want        string
+	}{
+		"AcceptsAValidContributor": {
+			contributor: &v1alpha1.Contributor{
+				ObjectMeta: metav1.ObjectMeta{Name: "gamora", Namespace: "starlord"},
+				Spec:       v1alpha1.ContributorSpec{Name: "gamora@guardians.net", Role: "Contributor"},
+			},
+			initObjs: []client.Object{profileNamespace()},
+		},
+		"RejectsAnUnknownRole": {
+			contributor: &v1alpha1.Contributor{
+				ObjectMeta: metav1.ObjectMeta{Name: "gamora", Namespace: "starlord"},
+				Spec:       v1alpha1.ContributorSpec{Name: "gamora@guardians.net", Role: "admin"},
+			},
+			initObjs: []client.Object{profileNamespace()},
+			want:     `spec.role: Unsupported value: "admin": supported values: "Contributor", "Owner"`,
+		},
+		"RejectsADuplicateUser": {
+			contributor: &v1alpha1.Contributor{
+				ObjectMeta: metav1.ObjectMeta{Name: "gamora-2", Namespace: "starlord"},
+				Spec:       v1alpha1.ContributorSpec{Name: "gamora@guardians.net", Role: "Contributor"},
+			},
+			initObjs: []client.Object{
+				profileNamespace(),
+				&v1alpha1.Contributor{
+					ObjectMeta: metav1.ObjectMeta{Name: "gamora", Namespace: "starlord"},
+					Spec:       v1alpha1.ContributorSpec{Name: "gamora@guardians.net", Role: "Contributor"},
+				},
+			},
+			want: `spec.name: Duplicate value: "gamora@guardians.net"`,
+		},
+		"AcceptsADuplicateUserManagedByTheProfile": {
+			contributor: &v1alpha1.Contributor{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "gamora",
+					Namespace:       "starlord",
+					OwnerReferences: profileNamespace().OwnerReferences,
+				},
+				Spec: v1alpha1.ContributorSpec{Name: "gamora@guardians.net", Role: "Owner"},
+			},
+			initObjs: []client.Object{
+				profileNamespace(),
+				&v1alpha1.Contributor{
+					ObjectMeta: metav1.ObjectMeta{Name: "gamora-edit", Namespace: "starlord"},
+					Spec:       v1alpha1.ContributorSpec{Name: "gamora@guardians.net", Role: "Contributor"},
+				},
+			},
+		},
+		"RejectsANamespaceWithoutAProfile": {
+			contributor: &v1alpha1.Contributor{
+				ObjectMeta: metav1.ObjectMeta{Name: "gamora", Namespace: "default"},
+				Spec:       v1alpha1.ContributorSpec{Name: "gamora@guardians.net", Role: "Contributor"},
+			},
+			initObjs: []client.Object{
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+			},
+			want: `metadata.namespace: Forbidden: namespace "default" does not belong to a profile`,
+		},
+	}
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	for name, subtest := range cases {
+		t.Run(name, func(t *testing.T) {
+
+			k8s := fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(subtest.initObjs...).
+				Build()
+
+			v := NewValidator(manager.FromClient(k8s))
+			err := v.ValidateCreate(ctx, subtest.contributor)
+			if subtest.want == "" {
+				qt.Assert(t, err, qt.IsNil)
+				return
+			}
+			qt.Assert(t, apierrors.IsInvalid(err), qt.IsTrue)
+			qt.Assert(t, err.Error(), qt.Contains, subtest.want)
+		})
+	}
+}
+
+func TestValidator_ValidateDelete(t *testing.T) {
+
+	owner := func() *v1alpha1.Contributor {
+		return &v1alpha1.Contributor{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "starlord",
+				Namespace: "starlord",
+				OwnerReferences: []metav1.OwnerReference{{
+					Name:               "starlord",
+					Kind:               "Profile",
+					APIVersion:         "kubeflow.org/v1alpha1",
+					UID:                "1234",
+					Controller:         pointer.Bool(true),
+					BlockOwnerDeletion: pointer.Bool(true),
+				}},
+			},
+			Spec: v1alpha1.ContributorSpec{Name: "starlord@guardians.net", Role: "Owner"},
+		}
+	}
+
+	cases := map[string]struct {
+		contributor *v1alpha1.Contributor
+		initObjs    []client.Object
+		forbidden   bool
+	}{
+		"RejectsDeletingTheOwnerContributor": {
+			contributor: owner(),
+			initObjs: []client.Object{
+				profileNamespace(),
+				&v1alpha1.Profile{
+					ObjectMeta: metav1.ObjectMeta{Name: "starlord", UID: "1234"},
+					Spec: v1alpha1.ProfileSpec{
+						Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+					},
+				},
+			},
+			forbidden: true,
+		},
+		"AcceptsDeletingTheOwnerContributorAfterTheProfile": {
+			contributor: owner(),
+			initObjs:    []client.Object{profileNamespace()},
+		},
+		"AcceptsDeletingACopyOfTheOwnerContributor": {
+			contributor: func() *v1alpha1.Contributor {
+				c := owner()
+				c.Labels = map[string]string{v1alpha1.LabelSourceNamespace: "starlord"}
+				return c
+			}(),
+			initObjs: []client.Object{
+				profileNamespace(),
+				&v1alpha1.Profile{
+					ObjectMeta: metav1.ObjectMeta{Name: "starlord", UID: "1234"},
+					Spec: v1alpha1.ProfileSpec{
+						Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+					},
+				},
+			},
+		},
+		"AcceptsDeletingAContributor": {
+			contributor: &v1alpha1.Contributor{
+				ObjectMeta: metav1.ObjectMeta{Name: "gamora", Namespace: "starlord"},
+				Spec:       v1alpha1.ContributorSpec{Name: "gamora@guardians.net", Role: "Contributor"},
+			},
+			initObjs: []client.Object{profileNamespace()},
+		},
+	}
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	for name, subtest := range cases {
+		t.Run(name, func(t *testing.T) {
+
+			k8s := fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(subtest.initObjs...).
+				Build()
+
+			v := NewValidator(manager.FromClient(k8s))
+			err := v.ValidateDelete(ctx, subtest.contributor)
+			if !subtest.forbidden {
+				qt.Assert(t, err, qt.IsNil)
+				return
+			}
+			qt.Assert(t, apierrors.IsForbidden(err), qt.IsTrue)
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package profile
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/johnhoman/kubeflow-profile-manager/apis/v1alpha1"
+	"github.com/johnhoman/kubeflow-profile-manager/controller"
+	"github.com/johnhoman/kubeflow-profile-manager/controller/features"
+	"github.com/johnhoman/kubeflow-profile-manager/controller/manager"
+)
+
+const (
+	errNotProfile    = "object is not a Profile"
+	errReadNamespace = "failed to read namespace"
+)
+
+// DefaultReservedNamespaces are namespaces that a profile may never claim
+var DefaultReservedNamespaces = []string{
+	"default",
+	"kube-system",
+	"kube-public",
+	"kube-node-lease",
+	"kubeflow",
+	"istio-system",
+	"knative-serving",
+	"knative-eventing",
+	"cert-manager",
+}
+
+// +kubebuilder:webhook:path=/validate-kubeflow-org-v1alpha1-profile,mutating=false,failurePolicy=fail,sideEffects=None,groups=kubeflow.org,resources=profiles,verbs=create;update;delete,versions=v1alpha1,name=vprofile.kubeflow.org,admissionReviewVersions=v1
+
+func Setup(mgr ctrl.Manager, o controller.Options, opts ...ValidatorOption) error {
+
+	opts = append(opts, WithLogger(o.Logger.WithValues("webhook", "profile-validator")))
+	if o.Features.Enabled(features.NamespaceAdoption) {
+		opts = append(opts, WithNamespaceAdoptionEnabled())
+	}
+
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&v1alpha1.Profile{}).
+		WithValidator(NewValidator(mgr, opts...)).
+		Complete()
+}
+
+type ValidatorOption func(v *Validator)
+
+func WithLogger(logger logging.Logger) ValidatorOption {
+	return func(v *Validator) {
+		v.logger = logger
+	}
+}
+
+func WithNamespaceAdoptionEnabled() ValidatorOption {
+	return func(v *Validator) {
+		v.namespaceAdoptionEnabled = true
+	}
+}
+
+// WithReservedNamespaces replaces the namespaces that a profile may not claim
+func WithReservedNamespaces(names ...string) ValidatorOption {
+	return func(v *Validator) {
+		v.reservedNamespaces = sets.NewString(names...)
+	}
+}
+
+// WithOwnerKinds replaces the rbac subject kinds that may own a profile
+func WithOwnerKinds(kinds ...string) ValidatorOption {
+	return func(v *Validator) {
+		v.ownerKinds = sets.NewString(kinds...)
+	}
+}
+
+// NewValidator returns a Profile validator that rejects invalid names, missing or
+// unsupported owners, reserved namespaces and namespaces owned by someone else
+func NewValidator(mgr manager.Manager, opts ...ValidatorOption) *Validator {
+	v := &Validator{
+		client:             mgr.GetClient(),
+		logger:             logging.NewNopLogger(),
+		reservedNamespaces: sets.NewString(DefaultReservedNamespaces...),
+		ownerKinds:         sets.NewString(rbacv1.UserKind, rbacv1.GroupKind),
+	}
+	for _, f := range opts {
+		f(v)
+	}
+	return v
+}
+
+type Validator struct {
+	client client.Client
+	logger logging.Logger
+
+	namespaceAdoptionEnabled bool
+	reservedNamespaces       sets.String
+	ownerKinds               sets.String
+}
+
+func (v *Validator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	profile, ok := obj.(*v1alpha1.Profile)
+	if !ok {
+		return errors.New(errNotProfile)
+	}
+
+	errs := v.validate(profile)
+	if len(errs) == 0 {
+		fieldErr, err := v.validateNamespaceOwner(ctx, profile, namespacePath(profile), profile.TargetNamespace())
+		if err != nil {
+			return err
+		}
+		if fieldErr != nil {
+			errs = append(errs, fieldErr)
+		}
+		for k, ns := range profile.Spec.Namespaces {
+			fieldErr, err := v.validateNamespaceOwner(ctx, profile, field.NewPath("spec", "namespaces").Index(k).Child("name"), ns.Name)
+			if err != nil {
+				return err
+			}
+			if fieldErr != nil {
+				errs = append(errs, fieldErr)
+			}
+		}
+	}
+	return invalid(profile, errs)
+}
+
+func (v *Validator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	profile, ok := newObj.(*v1alpha1.Profile)
+	if !ok {
+		return errors.New(errNotProfile)
+	}
+	old, ok := oldObj.(*v1alpha1.Profile)
+	if !ok {
+		return errors.New(errNotProfile)
+	}
+	// the controller patches finalizers onto profiles that were admitted by
+	// older validation rules, and a profile being deleted is only waiting for
+	// its finalizers to be removed
+	if profile.DeletionTimestamp != nil || equality.Semantic.DeepEqual(old.Spec, profile.Spec) {
+		return nil
+	}
+	errs := field.ErrorList{}
+	if profile.Spec.Namespace != old.Spec.Namespace {
+		errs = append(errs, field.Invalid(field.NewPath("spec", "namespace"), profile.Spec.Namespace, "field is immutable"))
+	}
+	if !equality.Semantic.DeepEqual(old.Spec.Owner, profile.Spec.Owner) {
+		errs = append(errs, v.validateOwner(profile)...)
+	}
+	if !equality.Semantic.DeepEqual(old.Spec.Namespaces, profile.Spec.Namespaces) {
+		errs = append(errs, v.validateNamespaces(profile)...)
+	}
+	if !equality.Semantic.DeepEqual(old.Spec.ResourceQuotas, profile.Spec.ResourceQuotas) {
+		errs = append(errs, v.validateResourceQuotas(profile)...)
+	}
+	if !equality.Semantic.DeepEqual(old.Spec.Istio, profile.Spec.Istio) {
+		errs = append(errs, v.validateIstio(profile)...)
+	}
+	if len(errs) == 0 {
+		for k, ns := range profile.Spec.Namespaces {
+			if hasNamespace(old, ns.Name) {
+				continue
+			}
+			fieldErr, err := v.validateNamespaceOwner(ctx, profile, field.NewPath("spec", "namespaces").Index(k).Child("name"), ns.Name)
+			if err != nil {
+				return err
+			}
+			if fieldErr != nil {
+				errs = append(errs, fieldErr)
+			}
+		}
+	}
+	return invalid(profile, errs)
+}
+
+// ValidateDelete rejects deleting a profile with deletion protection enabled
+func (v *Validator) ValidateDelete(_ context.Context, obj runtime.Object) error {
+	profile, ok := obj.(*v1alpha1.Profile)
+	if !ok {
+		return errors.New(errNotProfile)
+	}
+	if profile.GetAnnotations()[v1alpha1.AnnotationDeletionProtection] != "true" {
+		return nil
+	}
+	return apierrors.NewForbidden(
+		schema.GroupResource{Group: v1alpha1.Group, Resource: "profiles"},
+		profile.Name,
+		errors.Errorf("deletion protection is enabled, remove the %s annotation to delete the profile", v1alpha1.AnnotationDeletionProtection),
+	)
+}
+
+func (v *Validator) validate(profile *v1alpha1.Profile) field.ErrorList {
+	subject := "profile name"
+	if profile.Spec.Namespace != "" {
+		subject = "profile namespace"
+	}
+	errs := v.validateNamespace(namespacePath(profile), profile.TargetNamespace(), subject)
+	errs = append(errs, v.validateNamespaces(profile)...)
+	errs = append(errs, v.validateResourceQuotas(profile)...)
+	errs = append(errs, v.validateIstio(profile)...)
+	return append(errs, v.validateOwner(profile)...)
+}
+
+// validateIstio validates the additional Istio access of a profile. A rule
+// without any fields would allow every request into the profile namespaces
+func (v *Validator) validateIstio(profile *v1alpha1.Profile) field.ErrorList {
+	errs := field.ErrorList{}
+	if profile.Spec.Istio == nil {
+		return errs
+	}
+	for k, host := range profile.Spec.Istio.EgressHosts {
+		namespace, name, ok := strings.Cut(host, "/")
+		if !ok || namespace == "" || name == "" {
+			errs = append(errs, field.Invalid(
+				field.NewPath("spec", "istio", "egressHosts").Index(k), host,
+				"egress host must be in namespace/dnsName format",
+			))
+		}
+	}
+	for k, rule := range profile.Spec.Istio.Rules {
+		if len(rule.Principals) > 0 || len(rule.Namespaces) > 0 || len(rule.Paths) > 0 || len(rule.Methods) > 0 {
+			continue
+		}
+		errs = append(errs, field.Required(
+			field.NewPath("spec", "istio", "rules").Index(k),
+			"rule must set at least one of principals, namespaces, paths or methods",
+		))
+	}
+	return errs
+}
+
+// validateResourceQuotas validates the named resource quotas of a profile
+func (v *Validator) validateResourceQuotas(profile *v1alpha1.Profile) field.ErrorList {
+	errs := field.ErrorList{}
+	for k, quota := range profile.Spec.ResourceQuotas {
+		name := field.NewPath("spec", "resourceQuotas").Index(k).Child("name")
+		if quota.Name == "kf-resource-quota" {
+			errs = append(errs, field.Forbidden(name, fmt.Sprintf("resource quota name %q is reserved for the profile resource quota", quota.Name)))
+			continue
+		}
+		for _, msg := range validation.IsDNS1123Subdomain(quota.Name) {
+			errs = append(errs, field.Invalid(name, quota.Name, msg))
+		}
+	}
+	return errs
+}
+
+// validateNamespaces validates the additional namespaces of a profile
+func (v *Validator) validateNamespaces(profile *v1alpha1.Profile) field.ErrorList {
+	errs := field.ErrorList{}
+	for k, ns := range profile.Spec.Namespaces {
+		name := field.NewPath("spec", "namespaces").Index(k).Child("name")
+		if ns.Name == profile.TargetNamespace() {
+			errs = append(errs, field.Duplicate(name, ns.Name))
+			continue
+		}
+		errs = append(errs, v.validateNamespace(name, ns.Name, "profile namespace")...)
+	}
+	return errs
+}
+
+func (v *Validator) validateNamespace(name *field.Path, namespace, subject string) field.ErrorList {
+	errs := field.ErrorList{}
+	for _, msg := range validation.IsDNS1123Label(namespace) {
+		errs = append(errs, field.Invalid(name, namespace, fmt.Sprintf("%s must be a valid namespace name: %s", subject, msg)))
+	}
+	if v.reservedNamespaces.Has(namespace) || strings.HasPrefix(namespace, "kube-") {
+		errs = append(errs, field.Forbidden(name, fmt.Sprintf("namespace %q is reserved for system use", namespace)))
+	}
+	return errs
+}
+
+func (v *Validator) validateOwner(profile *v1alpha1.Profile) field.ErrorList {
+	errs := field.ErrorList{}
+	owner := field.NewPath("spec", "owner")
+	if profile.Spec.Owner.Name == "" {
+		errs = append(errs, field.Required(owner.Child("name"), "profile owner name must not be empty"))
+	}
+	if !v.ownerKinds.Has(profile.Spec.Owner.Kind) {
+		errs = append(errs, field.NotSupported(owner.Child("kind"), profile.Spec.Owner.Kind, v.ownerKinds.List()))
+	}
+	return errs
+}
+
+// validateNamespaceOwner rejects a profile namespace that already exists and
+// is not owned by the profile owner. The ownership check is the same one
+// used by the profile reconciler.
+func (v *Validator) validateNamespaceOwner(ctx context.Context, profile *v1alpha1.Profile, name *field.Path, namespaceName string) (*field.Error, error) {
+	if v.namespaceAdoptionEnabled {
+		return nil, nil
+	}
+	namespace := &corev1.Namespace{}
+	if err := v.client.Get(ctx, client.ObjectKey{Name: namespaceName}, namespace); err != nil {
+		return nil, errors.Wrap(client.IgnoreNotFound(err), errReadNamespace)
+	}
+	if owner, ok := namespace.Annotations["owner"]; ok && owner == profile.Spec.Owner.Name {
+		return nil, nil
+	}
+	v.logger.Debug("rejecting profile for existing namespace", "namespace", namespace.Name)
+	return field.Forbidden(
+		name,
+		fmt.Sprintf("namespace %q already exists and is not owned by %q", namespace.Name, profile.Spec.Owner.Name),
+	), nil
+}
+
+// namespacePath returns the path of the field that names the profile namespace
+func namespacePath(profile *v1alpha1.Profile) *field.Path {
+	if profile.Spec.Namespace != "" {
+		return field.NewPath("spec", "namespace")
+	}
+	return field.NewPath("metadata", "name")
+}
+
+func hasNamespace(profile *v1alpha1.Profile, name string) bool {
+	for _, ns := range profile.Spec.Namespaces {
+		if ns.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func invalid(profile *v1alpha1.Profile, errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(v1alpha1.GroupVersion.WithKind(v1alpha1.ProfileKind).GroupKind(), profile.Name, errs)
+}
+
+var _ admission.CustomValidator = &Validator{}
@@ -0,0 +1,485 @@
+package profile
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/johnhoman/kubeflow-profile-manager/apis/v1alpha1"
+	"github.com/johnhoman/kubeflow-profile-manager/controller/manager"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestValidator_ValidateCreate(t *testing.T) {
+
+	cases := map[string]struct {
+		profile  *v1alpha1.Profile
+		opts     []ValidatorOption
+		initObjs []client.Object
+		want     string
+	}{
+		"AcceptsAValidProfile": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+				},
+			},
+		},
+		"RejectsAnInvalidNamespaceName": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "Star.Lord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+				},
+			},
+			want: `metadata.name: Invalid value: "Star.Lord": profile name must be a valid namespace name`,
+		},
+		"RejectsAnEmptyOwner": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User"},
+				},
+			},
+			want: "spec.owner.name: Required value: profile owner name must not be empty",
+		},
+		"RejectsAnUnsupportedOwnerKind": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "ServiceAccount", Name: "starlord"},
+				},
+			},
+			want: `spec.owner.kind: Unsupported value: "ServiceAccount": supported values: "Group", "User"`,
+		},
+		"RejectsAReservedNamespace": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "kubeflow"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+				},
+			},
+			want: `metadata.name: Forbidden: namespace "kubeflow" is reserved for system use`,
+		},
+		"RejectsAConfiguredReservedNamespace": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "monitoring"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+				},
+			},
+			opts: []ValidatorOption{WithReservedNamespaces("monitoring")},
+			want: `metadata.name: Forbidden: namespace "monitoring" is reserved for system use`,
+		},
+		"RejectsAnExistingNamespaceNotOwnedByTheProfile": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+				},
+			},
+			initObjs: []client.Object{
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "starlord"}},
+			},
+			want: `metadata.name: Forbidden: namespace "starlord" already exists and is not owned by "starlord@guardians.net"`,
+		},
+		"AcceptsAnExistingNamespaceOwnedByTheProfileOwner": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+				},
+			},
+			initObjs: []client.Object{
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+					Name:        "starlord",
+					Annotations: map[string]string{"owner": "starlord@guardians.net"},
+				}},
+			},
+		},
+		"AcceptsANamespaceThatIsNotTheProfileName": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner:     rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+					Namespace: "team-ml-prod",
+				},
+			},
+		},
+		"RejectsAReservedSpecNamespace": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner:     rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+					Namespace: "kube-system",
+				},
+			},
+			want: `spec.namespace: Forbidden: namespace "kube-system" is reserved for system use`,
+		},
+		"RejectsAnExistingSpecNamespaceNotOwnedByTheProfile": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner:     rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+					Namespace: "team-ml-prod",
+				},
+			},
+			initObjs: []client.Object{
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-ml-prod"}},
+			},
+			want: `spec.namespace: Forbidden: namespace "team-ml-prod" already exists and is not owned by "starlord@guardians.net"`,
+		},
+		"RejectsAReservedAdditionalNamespace": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner:      rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+					Namespaces: []v1alpha1.ProfileNamespace{{Name: "starlord-dev"}, {Name: "kubeflow"}},
+				},
+			},
+			want: `spec.namespaces[1].name: Forbidden: namespace "kubeflow" is reserved for system use`,
+		},
+		"RejectsAnExistingAdditionalNamespaceNotOwnedByTheProfile": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner:      rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+					Namespaces: []v1alpha1.ProfileNamespace{{Name: "starlord-dev"}},
+				},
+			},
+			initObjs: []client.Object{
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "starlord-dev"}},
+			},
+			want: `spec.namespaces[0].name: Forbidden: namespace "starlord-dev" already exists and is not owned by "starlord@guardians.net"`,
+		},
+		"RejectsAResourceQuotaNamedLikeTheProfileResourceQuota": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner:          rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+					ResourceQuotas: []v1alpha1.ProfileResourceQuota{{Name: "kf-resource-quota"}},
+				},
+			},
+			want: `spec.resourceQuotas[0].name: Forbidden: resource quota name "kf-resource-quota" is reserved`,
+		},
+		"RejectsAnEmptyIstioRule": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+					Istio: &v1alpha1.ProfileIstio{
+						Rules: []v1alpha1.ProfileIstioRule{{Paths: []string{"/v1/models/*"}}, {}},
+					},
+				},
+			},
+			want: `spec.istio.rules[1]: Required value: rule must set at least one of principals, namespaces, paths or methods`,
+		},
+		"RejectsAnEgressHostWithoutANamespace": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+					Istio: &v1alpha1.ProfileIstio{
+						EgressHosts: []string{"model-gateway/*", "example.com"},
+					},
+				},
+			},
+			want: `spec.istio.egressHosts[1]: Invalid value: "example.com": egress host must be in namespace/dnsName format`,
+		},
+		"AcceptsAnExistingNamespaceWhenAdoptionIsEnabled": {
+			profile: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+				},
+			},
+			opts: []ValidatorOption{WithNamespaceAdoptionEnabled()},
+			initObjs: []client.Object{
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "starlord"}},
+			},
+		},
+	}
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	for name, subtest := range cases {
+		t.Run(name, func(t *testing.T) {
+
+			k8s := fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(subtest.initObjs...).
+				Build()
+
+			v := NewValidator(manager.FromClient(k8s), subtest.opts...)
+			err := v.ValidateCreate(ctx, subtest.profile)
+			if subtest.want == "" {
+				qt.Assert(t, err, qt.IsNil)
+				return
+			}
+			qt.Assert(t, apierrors.IsInvalid(err), qt.IsTrue)
+			qt.Assert(t, err.Error(), qt.Contains, subtest.want)
+		})
+	}
+}
+
+func TestValidator_ValidateUpdate(t *testing.T) {
+
+	cases := map[string]struct {
+		old  *v1alpha1.Profile
+		new  *v1alpha1.Profile
+		want string
+	}{
+		"AcceptsAnOwnerChange": {
+			old: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+				},
+			},
+			new: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "gamora@guardians.net"},
+				},
+			},
+		},
+		"RejectsRemovingTheOwner": {
+			old: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+				},
+			},
+			new: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User"},
+				},
+			},
+			want: "spec.owner.name: Required value: profile owner name must not be empty",
+		},
+		"RejectsChangingTheNamespace": {
+			old: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+				},
+			},
+			new: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner:     rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+					Namespace: "team-ml-prod",
+				},
+			},
+			want: `spec.namespace: Invalid value: "team-ml-prod": field is immutable`,
+		},
+		"AcceptsAddingANamespace": {
+			old: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+				},
+			},
+			new: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner:      rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+					Namespaces: []v1alpha1.ProfileNamespace{{Name: "starlord-dev"}},
+				},
+			},
+		},
+		"RejectsAnAdditionalNamespaceThatIsTheProfileNamespace": {
+			old: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+				},
+			},
+			new: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner:      rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+					Namespaces: []v1alpha1.ProfileNamespace{{Name: "starlord"}},
+				},
+			},
+			want: `spec.namespaces[0].name: Duplicate value: "starlord"`,
+		},
+		"RejectsAddingTheProfileResourceQuotaName": {
+			old: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+				},
+			},
+			new: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner:          rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+					ResourceQuotas: []v1alpha1.ProfileResourceQuota{{Name: "kf-resource-quota"}},
+				},
+			},
+			want: `spec.resourceQuotas[0].name: Forbidden: resource quota name "kf-resource-quota" is reserved`,
+		},
+		"RejectsAddingAnEmptyIstioRule": {
+			old: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+					Istio: &v1alpha1.ProfileIstio{
+						Rules: []v1alpha1.ProfileIstioRule{{Paths: []string{"/v1/models/*"}}},
+					},
+				},
+			},
+			new: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+					Istio: &v1alpha1.ProfileIstio{
+						Rules: []v1alpha1.ProfileIstioRule{{Paths: []string{"/v1/models/*"}}, {}},
+					},
+				},
+			},
+			want: `spec.istio.rules[1]: Required value: rule must set at least one of principals, namespaces, paths or methods`,
+		},
+		"RejectsAddingAMalformedEgressHost": {
+			old: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+				},
+			},
+			new: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+					Istio: &v1alpha1.ProfileIstio{
+						EgressHosts: []string{"example.com"},
+					},
+				},
+			},
+			want: `spec.istio.egressHosts[0]: Invalid value: "example.com": egress host must be in namespace/dnsName format`,
+		},
+		"AcceptsAFinalizerOnAnInvalidProfile": {
+			old: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "kube-public"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User"},
+				},
+			},
+			new: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "kube-public", Finalizers: []string{"profiles.kubeflow.org/finalizer"}},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User"},
+				},
+			},
+		},
+		"AcceptsAnUpdateToADeletingInvalidProfile": {
+			old: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "kube-public",
+					DeletionTimestamp: &metav1.Time{Time: time.Now()},
+					Finalizers:        []string{"profiles.kubeflow.org/finalizer"},
+				},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User"},
+				},
+			},
+			new: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "kube-public",
+					DeletionTimestamp: &metav1.Time{Time: time.Now()},
+				},
+				Spec: v1alpha1.ProfileSpec{
+					Owner:      rbacv1.Subject{Kind: "User"},
+					Namespaces: []v1alpha1.ProfileNamespace{{Name: "kube-system"}},
+				},
+			},
+		},
+		"AcceptsAnUnchangedFieldThatIsInvalid": {
+			old: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+					Istio: &v1alpha1.ProfileIstio{
+						EgressHosts: []string{"example.com"},
+					},
+				},
+			},
+			new: &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord"},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "gamora@guardians.net"},
+					Istio: &v1alpha1.ProfileIstio{
+						EgressHosts: []string{"example.com"},
+					},
+				},
+			},
+		},
+	}
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	for name, subtest := range cases {
+		t.Run(name, func(t *testing.T) {
+
+			k8s := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+
+			v := NewValidator(manager.FromClient(k8s))
+			err := v.ValidateUpdate(ctx, subtest.old, subtest.new)
+			if subtest.want == "" {
+				qt.Assert(t, err, qt.IsNil)
+				return
+			}
+			qt.Assert(t, apierrors.IsInvalid(err), qt.IsTrue)
+			qt.Assert(t, err.Error(), qt.Contains, subtest.want)
+		})
+	}
+}
+
+func TestValidator_ValidateDelete(t *testing.T) {
+	cases := map[string]struct {
+		annotations map[string]string
+		want        string
+	}{
+		"AcceptsAnUnprotectedProfile": {},
+		"AcceptsDisabledDeletionProtection": {
+			annotations: map[string]string{v1alpha1.AnnotationDeletionProtection: "false"},
+		},
+		"RejectsAProtectedProfile": {
+			annotations: map[string]string{v1alpha1.AnnotationDeletionProtection: "true"},
+			want:        "deletion protection is enabled",
+		},
+	}
+	qt.Assert(t, v1alpha1.AddToScheme(scheme.Scheme), qt.IsNil)
+
+	ctx := context.Background()
+	for name, subtest := range cases {
+		t.Run(name, func(t *testing.T) {
+
+			k8s := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+
+			v := NewValidator(manager.FromClient(k8s))
+			err := v.ValidateDelete(ctx, &v1alpha1.Profile{
+				ObjectMeta: metav1.ObjectMeta{Name: "starlord", Annotations: subtest.annotations},
+				Spec: v1alpha1.ProfileSpec{
+					Owner: rbacv1.Subject{Kind: "User", Name: "starlord@guardians.net"},
+				},
+			})
+			if subtest.want == "" {
+				qt.Assert(t, err, qt.IsNil)
+				return
+			}
+			qt.Assert(t, apierrors.IsForbidden(err), qt.IsTrue)
+			qt.Assert(t, err.Error(), qt.Contains, subtest.want)
+		})
+	}
+}